@@ -0,0 +1,313 @@
+// cmd/dlq-tool/main.go
+//
+// Ferramenta de linha de comando para operar a dead-letter queue do tópico de
+// pedidos:
+//
+//	dlq-tool list [-limit 50]
+//	dlq-tool inspect -partition 0 -offset 42
+//	dlq-tool replay -partition 0 -offset 42
+//	dlq-tool replay -all
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mlucas4330/orderflow-pro/internal/config"
+	"github.com/mlucas4330/orderflow-pro/internal/messaging/consumer"
+	kafka "github.com/segmentio/kafka-go"
+)
+
+const (
+	replayGroupID = "dlq-tool-replay"
+	// readTimeout limita a espera por mensagens, para que a ferramenta não
+	// fique presa numa partição vazia ou parada.
+	readTimeout = 10 * time.Second
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	cfg := config.LoadDLQConfig()
+	tool := &dlqTool{
+		brokers:     strings.Split(cfg.KafkaBrokers, ","),
+		sourceTopic: cfg.SourceTopic,
+		dlqTopic:    consumer.DeadLetterTopic(cfg.SourceTopic),
+	}
+
+	ctx := context.Background()
+
+	var err error
+	switch os.Args[1] {
+	case "list":
+		fs := flag.NewFlagSet("list", flag.ExitOnError)
+		limit := fs.Int("limit", 50, "número máximo de mensagens por partição")
+		fs.Parse(os.Args[2:])
+		err = tool.list(ctx, *limit)
+	case "inspect":
+		fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+		partition := fs.Int("partition", 0, "partição da mensagem na DLQ")
+		offset := fs.Int64("offset", -1, "offset da mensagem na DLQ")
+		fs.Parse(os.Args[2:])
+		err = tool.inspect(ctx, *partition, *offset)
+	case "replay":
+		fs := flag.NewFlagSet("replay", flag.ExitOnError)
+		partition := fs.Int("partition", 0, "partição da mensagem na DLQ")
+		offset := fs.Int64("offset", -1, "offset da mensagem na DLQ")
+		all := fs.Bool("all", false, "reprocessa todas as mensagens ainda não reprocessadas")
+		fs.Parse(os.Args[2:])
+		if *all {
+			err = tool.replayAll(ctx)
+		} else {
+			err = tool.replayOne(ctx, *partition, *offset)
+		}
+	default:
+		usage()
+	}
+
+	if err != nil {
+		log.Fatalf("Erro: %v", err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "uso: dlq-tool <list|inspect|replay> [opções]")
+	os.Exit(2)
+}
+
+type dlqTool struct {
+	brokers     []string
+	sourceTopic string
+	dlqTopic    string
+}
+
+func (t *dlqTool) list(ctx context.Context, limit int) error {
+	conn, err := kafka.DialContext(ctx, "tcp", t.brokers[0])
+	if err != nil {
+		return fmt.Errorf("erro ao conectar ao Kafka: %w", err)
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions(t.dlqTopic)
+	if err != nil {
+		return fmt.Errorf("erro ao ler partições de %s: %w", t.dlqTopic, err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PARTIÇÃO\tOFFSET\tEVENTO\tCHAVE\tTENTATIVAS\tFALHOU EM\tÚLTIMO ERRO")
+
+	for _, p := range partitions {
+		messages, err := t.readPartition(ctx, p.ID, limit)
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range messages {
+			attempts, _ := consumer.Header(msg, consumer.HeaderDLQAttempts)
+			failedAt, _ := consumer.Header(msg, consumer.HeaderDLQFailedAt)
+			lastError, _ := consumer.Header(msg, consumer.HeaderDLQLastError)
+			fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\t%s\n", msg.Partition, msg.Offset, consumer.EventType(msg), msg.Key, attempts, failedAt, truncate(lastError, 80))
+		}
+	}
+
+	return w.Flush()
+}
+
+func (t *dlqTool) inspect(ctx context.Context, partition int, offset int64) error {
+	msg, err := t.readAt(ctx, partition, offset)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Partição: %d\nOffset:   %d\nChave:    %s\nData:     %s\n\nCabeçalhos:\n", msg.Partition, msg.Offset, msg.Key, msg.Time.Format(time.RFC3339))
+	for _, header := range msg.Headers {
+		fmt.Printf("  %s: %s\n", header.Key, header.Value)
+	}
+
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, msg.Value, "", "  "); err != nil {
+		fmt.Printf("\nPayload (bruto):\n%s\n", msg.Value)
+		return nil
+	}
+	fmt.Printf("\nPayload:\n%s\n", pretty.String())
+	return nil
+}
+
+func (t *dlqTool) replayOne(ctx context.Context, partition int, offset int64) error {
+	msg, err := t.readAt(ctx, partition, offset)
+	if err != nil {
+		return err
+	}
+
+	writer := t.writer()
+	defer writer.Close()
+
+	return t.replay(ctx, writer, msg)
+}
+
+// replayAll usa um consumer group próprio para que cada mensagem da DLQ seja
+// reprocessada uma única vez, mesmo em execuções repetidas da ferramenta.
+func (t *dlqTool) replayAll(ctx context.Context) error {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: t.brokers,
+		Topic:   t.dlqTopic,
+		GroupID: replayGroupID,
+	})
+	defer reader.Close()
+
+	writer := t.writer()
+	defer writer.Close()
+
+	replayed := 0
+	for {
+		fetchCtx, cancel := context.WithTimeout(ctx, readTimeout)
+		msg, err := reader.FetchMessage(fetchCtx)
+		cancel()
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				break
+			}
+			return fmt.Errorf("erro ao ler mensagem da DLQ: %w", err)
+		}
+
+		if err := t.replay(ctx, writer, msg); err != nil {
+			return err
+		}
+
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			return fmt.Errorf("erro ao fazer commit da mensagem reprocessada: %w", err)
+		}
+		replayed++
+	}
+
+	log.Printf("%d mensagem(ns) reprocessada(s) de %s para %s.", replayed, t.dlqTopic, t.sourceTopic)
+	return nil
+}
+
+func (t *dlqTool) replay(ctx context.Context, writer *kafka.Writer, msg kafka.Message) error {
+	headers := append(consumer.OriginalHeaders(msg), kafka.Header{
+		Key:   consumer.HeaderDLQReplayedFrom,
+		Value: []byte(strconv.Itoa(msg.Partition) + "/" + strconv.FormatInt(msg.Offset, 10)),
+	})
+
+	err := writer.WriteMessages(ctx, kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao reprocessar mensagem %d/%d: %w", msg.Partition, msg.Offset, err)
+	}
+
+	log.Printf("Mensagem %d/%d (%s) reenviada para %s.", msg.Partition, msg.Offset, consumer.EventType(msg), t.sourceTopic)
+	return nil
+}
+
+func (t *dlqTool) writer() *kafka.Writer {
+	return &kafka.Writer{
+		Addr:     kafka.TCP(t.brokers...),
+		Topic:    t.sourceTopic,
+		Balancer: &kafka.Hash{},
+	}
+}
+
+func (t *dlqTool) readPartition(ctx context.Context, partition int, limit int) ([]kafka.Message, error) {
+	conn, err := kafka.DialLeader(ctx, "tcp", t.brokers[0], t.dlqTopic, partition)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar ao líder da partição %d: %w", partition, err)
+	}
+	first, last, err := conn.ReadOffsets()
+	conn.Close()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler offsets da partição %d: %w", partition, err)
+	}
+
+	if first >= last {
+		return nil, nil
+	}
+	if last-first > int64(limit) {
+		first = last - int64(limit)
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   t.brokers,
+		Topic:     t.dlqTopic,
+		Partition: partition,
+	})
+	defer reader.Close()
+
+	if err := reader.SetOffset(first); err != nil {
+		return nil, fmt.Errorf("erro ao posicionar leitor na partição %d: %w", partition, err)
+	}
+
+	// O high-water mark pode apontar para offsets que nunca chegam ao leitor
+	// (registros de controle, mensagens apagadas pela retenção), então a leitura
+	// também para no timeout e devolve o que já foi lido.
+	readCtx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	var messages []kafka.Message
+	for offset := first; offset < last; {
+		msg, err := reader.ReadMessage(readCtx)
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			log.Printf("AVISO: Tempo esgotado lendo a partição %d; exibindo %d mensagem(ns).", partition, len(messages))
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler mensagem da partição %d: %w", partition, err)
+		}
+		messages = append(messages, msg)
+		offset = msg.Offset + 1
+	}
+
+	return messages, nil
+}
+
+func (t *dlqTool) readAt(ctx context.Context, partition int, offset int64) (kafka.Message, error) {
+	if offset < 0 {
+		return kafka.Message{}, errors.New("informe o offset da mensagem com -offset")
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   t.brokers,
+		Topic:     t.dlqTopic,
+		Partition: partition,
+	})
+	defer reader.Close()
+
+	if err := reader.SetOffset(offset); err != nil {
+		return kafka.Message{}, fmt.Errorf("erro ao posicionar leitor: %w", err)
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	msg, err := reader.ReadMessage(readCtx)
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("mensagem %d/%d não encontrada na DLQ: %w", partition, offset, err)
+	}
+	if msg.Offset != offset {
+		return kafka.Message{}, fmt.Errorf("mensagem %d/%d não encontrada na DLQ", partition, offset)
+	}
+
+	return msg, nil
+}
+
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit-3] + "..."
+}
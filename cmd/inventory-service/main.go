@@ -5,13 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"strings"
-	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/mlucas4330/orderflow-pro/internal/config"
 	"github.com/mlucas4330/orderflow-pro/internal/events"
+	"github.com/mlucas4330/orderflow-pro/internal/messaging/consumer"
+	"github.com/mlucas4330/orderflow-pro/internal/messaging/outbox"
+	"github.com/mlucas4330/orderflow-pro/internal/messaging/producer"
 	"github.com/mlucas4330/orderflow-pro/internal/repository"
+	"github.com/mlucas4330/orderflow-pro/internal/reservation"
+	"github.com/mlucas4330/orderflow-pro/pkg/model"
 	kafka "github.com/segmentio/kafka-go"
 )
 
@@ -27,60 +31,118 @@ func main() {
 		log.Fatalf("Falha ao conectar com o banco de dados: %v", err)
 	}
 
-	inventoryRepo := repository.NewInventoryRepository(dbpool)
+	inventoryRepo := repository.NewInventoryRepository(dbpool, cfg.ReservationTTL)
 
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:     strings.Split(cfg.KafkaBrokers, ","),
-		Topic:       "orders",
-		GroupID:     "inventory-service",
-		Logger:      kafka.LoggerFunc(log.Printf),
-		ErrorLogger: kafka.LoggerFunc(log.Printf),
-	})
-	defer reader.Close()
+	kafkaProducer := producer.NewKafkaProducer(cfg.KafkaBrokers)
+	defer kafkaProducer.Close()
+
+	// StockReserved/StockRejected são gravados no outbox junto com a baixa de
+	// estoque; este relay publica apenas o tópico do inventário.
+	outboxRelay := outbox.NewRelay(repository.NewOutboxRepository(dbpool), kafkaProducer, nil, []string{events.InventoryTopic}, cfg.OutboxPollInterval)
+	go outboxRelay.Run(ctx)
+
+	sweeper := reservation.NewSweeper(inventoryRepo, cfg.SweepInterval)
+	go sweeper.Run(ctx)
+
+	orderConsumer := consumer.NewKafkaConsumer(cfg.KafkaBrokers, events.OrdersTopic, "inventory-service")
+	orderConsumer.EnableDeadLetter(cfg.KafkaBrokers, consumer.DeadLetterTopic(events.OrdersTopic))
+	defer orderConsumer.Close()
 
-	log.Println("Serviço de inventário iniciado. A ouvir por eventos de 'order.created'...")
+	orderConsumer.Subscribe(events.OrderCreatedType, func(ctx context.Context, msg kafka.Message) error {
+		var event events.OrderCreatedEvent
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			return consumer.Permanent(fmt.Errorf("erro ao desserializar evento OrderCreated: %w", err))
+		}
 
-	for {
-		msg, err := reader.FetchMessage(ctx)
+		outcome, err := inventoryRepo.ProcessOrderCreated(ctx, event)
 		if err != nil {
-			log.Printf("Erro ao buscar mensagem do Kafka: %v", err)
-			continue
+			return fmt.Errorf("erro ao baixar o estoque do pedido %s: %w", event.OrderID, err)
 		}
 
-		var event events.OrderCreatedEvent
+		switch outcome {
+		case repository.StockOutcomeReserved:
+			log.Printf("Estoque do pedido %s reservado até o pagamento.", event.OrderID)
+		case repository.StockOutcomeRejected:
+			log.Printf("Estoque insuficiente para o pedido %s, reserva rejeitada.", event.OrderID)
+		default:
+			log.Printf("Evento OrderCreated do pedido %s já processado, reentrega ignorada.", event.OrderID)
+		}
+		return nil
+	})
+
+	orderConsumer.Subscribe(events.OrderStatusChangedType, func(ctx context.Context, msg kafka.Message) error {
+		var event events.OrderStatusChangedEvent
 		if err := json.Unmarshal(msg.Value, &event); err != nil {
-			log.Printf("Erro ao desserializar evento OrderCreated: %v", err)
-			continue
+			return consumer.Permanent(fmt.Errorf("erro ao desserializar evento OrderStatusChanged: %w", err))
 		}
 
-		for _, item := range event.Items {
-			const maxAttempts = 3
-			var err error
+		if event.NewStatus != model.StatusPaid {
+			return nil
+		}
 
-			for attempt := 1; attempt <= maxAttempts; attempt++ {
-				log.Printf("Tentativa %d de atualizar estoque para o produto %s: -%d", attempt, item.ProductID, item.Quantity)
+		outcome, err := inventoryRepo.CommitReservation(ctx, event.OrderID, event.CustomerID)
+		if err != nil {
+			return fmt.Errorf("erro ao efetivar a reserva do pedido %s: %w", event.OrderID, err)
+		}
 
-				err = inventoryRepo.DecrementStock(ctx, item.ProductID, item.Quantity)
+		switch outcome {
+		case repository.StockOutcomeCommitted:
+			log.Printf("Reserva do pedido %s efetivada após o pagamento.", event.OrderID)
+		case repository.StockOutcomeRejected:
+			log.Printf("Reserva vencida do pedido pago %s não pôde ser efetivada, estorno solicitado.", event.OrderID)
+		default:
+			log.Printf("Pedido %s sem reserva pendente, evento ignorado.", event.OrderID)
+		}
+		return nil
+	})
 
-				if err == nil {
-					log.Printf("Estoque para o produto %s atualizado com sucesso.", item.ProductID)
-					break
-				}
+	restock := func(ctx context.Context, orderID uuid.UUID, reason string) error {
+		applied, err := inventoryRepo.RestockOrder(ctx, orderID, reason)
+		if err != nil {
+			return fmt.Errorf("erro ao repor estoque do pedido %s: %w", orderID, err)
+		}
 
-				log.Printf("AVISO: Falha na tentativa %d para o produto %s: %v", attempt, item.ProductID, err)
+		if applied {
+			log.Printf("Estoque do pedido %s reposto (%s).", orderID, reason)
+		} else {
+			log.Printf("Estoque do pedido %s já havia sido reposto, evento ignorado.", orderID)
+		}
+		return nil
+	}
 
-				if attempt < maxAttempts {
-					time.Sleep(time.Duration(attempt) * time.Second)
-				}
-			}
+	orderConsumer.Subscribe(events.OrderCancelledType, func(ctx context.Context, msg kafka.Message) error {
+		var event events.OrderCancelledEvent
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			return consumer.Permanent(fmt.Errorf("erro ao desserializar evento OrderCancelled: %w", err))
+		}
+		return restock(ctx, event.OrderID, events.OrderCancelledType)
+	})
 
-			if err != nil {
-				log.Printf("ERRO FINAL: Todas as %d tentativas falharam para o produto %s. Erro: %v", maxAttempts, item.ProductID, err)
-			}
+	orderConsumer.Subscribe(events.OrderRefundedType, func(ctx context.Context, msg kafka.Message) error {
+		var event events.OrderRefundedEvent
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			return consumer.Permanent(fmt.Errorf("erro ao desserializar evento OrderRefunded: %w", err))
+		}
+		return restock(ctx, event.OrderID, events.OrderRefundedType)
+	})
+
+	orderConsumer.Subscribe(events.OrderDeletedType, func(ctx context.Context, msg kafka.Message) error {
+		var event events.OrderDeletedEvent
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			return consumer.Permanent(fmt.Errorf("erro ao desserializar evento OrderDeleted: %w", err))
 		}
 
-		if err := reader.CommitMessages(ctx, msg); err != nil {
-			log.Printf("Erro ao fazer commit da mensagem: %v", err)
+		// Pedidos já enviados não voltam ao estoque; cancelados e reembolsados
+		// já foram repostos pelos respectivos eventos.
+		if event.PreviousStatus == model.StatusShipped || event.PreviousStatus == model.StatusDelivered {
+			return nil
 		}
+		return restock(ctx, event.OrderID, events.OrderDeletedType)
+	})
+
+	log.Println("Serviço de inventário iniciado. A ouvir por eventos de pedidos...")
+
+	if err := orderConsumer.Run(ctx); err != nil {
+		log.Fatalf("Consumidor de pedidos finalizado: %v", err)
 	}
 }
@@ -1,16 +1,21 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 
 	"github.com/mlucas4330/orderflow-pro/internal/config"
+	"github.com/mlucas4330/orderflow-pro/internal/events"
 	"github.com/mlucas4330/orderflow-pro/internal/messaging/consumer"
 	"github.com/mlucas4330/orderflow-pro/pkg/messaging"
+	kafka "github.com/segmentio/kafka-go"
 )
 
 func main() {
+	ctx := context.Background()
+
 	cfg := config.LoadNotificationConfig()
 
 	rabbitmqUrl := fmt.Sprintf("amqp://%s:%s@%s:5672/", cfg.RabbitmqUser, cfg.RabbitmqPass, cfg.RabbitmqHost)
@@ -18,12 +23,25 @@ func main() {
 	rabbitConsumer := consumer.NewRabbitMQConsumer(rabbitmqUrl)
 	defer rabbitConsumer.Close()
 
-	msgs, err := rabbitConsumer.Consume("email_notifications")
+	msgs, err := rabbitConsumer.Consume(messaging.EmailNotificationsQueue)
 	if err != nil {
 		log.Fatalf("Falha ao consumir fila RabbitMQ: %s", err)
 		return
 	}
 
+	orderConsumer := consumer.NewKafkaConsumer(cfg.KafkaBrokers, events.OrdersTopic, "notification-service")
+	defer orderConsumer.Close()
+
+	orderConsumer.Subscribe(events.OrderStatusChangedType, func(ctx context.Context, msg kafka.Message) error {
+		var event events.OrderStatusChangedEvent
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			return consumer.Permanent(fmt.Errorf("erro ao desserializar evento OrderStatusChanged: %w", err))
+		}
+
+		log.Printf("TAREFA RECEBIDA: Notificando o cliente %s que o pedido %s passou de %s para %s.", event.CustomerID, event.OrderID, event.PreviousStatus, event.NewStatus)
+		return nil
+	})
+
 	forever := make(chan bool)
 
 	go func() {
@@ -44,6 +62,12 @@ func main() {
 		}
 	}()
 
+	go func() {
+		if err := orderConsumer.Run(ctx); err != nil {
+			log.Printf("Consumidor de eventos de pedido finalizado: %v", err)
+		}
+	}()
+
 	log.Printf("Serviço de notificação iniciado. Aguardando tarefas...")
 	<-forever
 }
@@ -4,15 +4,23 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/mlucas4330/orderflow-pro/internal/cache"
 	"github.com/mlucas4330/orderflow-pro/internal/config"
+	"github.com/mlucas4330/orderflow-pro/internal/events"
 	"github.com/mlucas4330/orderflow-pro/internal/handler"
+	"github.com/mlucas4330/orderflow-pro/internal/idempotency"
+	"github.com/mlucas4330/orderflow-pro/internal/messaging/consumer"
+	"github.com/mlucas4330/orderflow-pro/internal/messaging/outbox"
 	"github.com/mlucas4330/orderflow-pro/internal/messaging/producer"
 	"github.com/mlucas4330/orderflow-pro/internal/middleware"
 	"github.com/mlucas4330/orderflow-pro/internal/repository"
+	"github.com/mlucas4330/orderflow-pro/internal/saga"
+	"github.com/mlucas4330/orderflow-pro/internal/server"
+	"github.com/mlucas4330/orderflow-pro/pkg/messaging"
 	pb "github.com/mlucas4330/orderflow-pro/pkg/productpb"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
@@ -34,11 +42,12 @@ func main() {
 	}
 	defer dbpool.Close()
 
-	redisClient, err := cache.NewRedisClient(ctx, cfg.RedisAddr, cfg.RedisDB)
+	redisClient, redisBreaker, err := cache.NewRedisClient(ctx, cfg.Redis)
 	if err != nil {
-		log.Fatalf("Falha ao conectar com o redis: %v", err)
+		log.Fatalf("Configuração do Redis inválida: %v", err)
 	}
 	defer redisClient.Close()
+	go redisBreaker.Run(ctx)
 
 	kafkaProducer := producer.NewKafkaProducer(cfg.KafkaBrokers)
 	defer kafkaProducer.Close()
@@ -48,19 +57,64 @@ func main() {
 	rabbitProducer := producer.NewRabbitMQProducer(rabbitmqUrl)
 	defer rabbitProducer.Close()
 
-	grpcconn, err := grpc.NewClient(cfg.ProductServiceAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	grpcconn, err := grpc.NewClient(cfg.ProductServiceAddr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(server.NewServiceCredentials(cfg.ProductToken)),
+	)
 	if err != nil {
 		log.Fatalf("Falha ao conectar com o product-service via gRPC: %v", err)
 	}
 	defer grpcconn.Close()
 
-	orderRepository := repository.NewOrderRepository(dbpool, redisClient, kafkaProducer, rabbitProducer)
+	outboxTopics := []string{events.OrdersTopic, messaging.EmailNotificationsQueue}
+	outboxRelay := outbox.NewRelay(repository.NewOutboxRepository(dbpool), kafkaProducer, rabbitProducer, outboxTopics, cfg.OutboxPollInterval)
+	go outboxRelay.Run(ctx)
+
+	orderRepository := repository.NewOrderRepository(dbpool, redisClient)
+	redisBreaker.OnRecover(orderRepository.RecoverCache)
+	if cfg.LocalCacheSize > 0 {
+		orderRepository.Cache.Local = cache.NewLocalCache(cfg.LocalCacheSize, cfg.LocalCacheTTL)
+		go orderRepository.Cache.ListenInvalidations(ctx)
+	}
+
+	orderSaga := saga.NewOrderSaga(orderRepository)
+	inventoryConsumer := consumer.NewKafkaConsumer(cfg.KafkaBrokers, events.InventoryTopic, "order-service")
+	inventoryConsumer.EnableDeadLetter(cfg.KafkaBrokers, consumer.DeadLetterTopic(events.InventoryTopic))
+	defer inventoryConsumer.Close()
+
+	inventoryConsumer.Subscribe(events.StockReservedType, orderSaga.HandleStockReserved)
+	inventoryConsumer.Subscribe(events.StockRejectedType, orderSaga.HandleStockRejected)
+	inventoryConsumer.Subscribe(events.StockExpiredType, orderSaga.HandleStockExpired)
+
+	go func() {
+		if err := inventoryConsumer.Run(ctx); err != nil {
+			log.Printf("Consumidor de eventos de estoque finalizado: %v", err)
+		}
+	}()
 	idempotencyRepository := repository.NewIdempotencyRepository(dbpool)
-	healthHandler := handler.NewHealthHandler(dbpool)
+	go idempotency.NewPurger(idempotencyRepository, cfg.IdempotencyPurge).Run(ctx)
+	healthHandler := handler.NewHealthHandler(dbpool, redisBreaker)
 	productClient := pb.NewProductServiceClient(grpcconn)
-	orderHandler := handler.NewOrderHandler(orderRepository, idempotencyRepository, productClient)
+	orderHandler := handler.NewOrderHandler(orderRepository, productClient)
+	productHandler := handler.NewProductHandler(productClient)
+
+	authOptions := []middleware.AuthOption{middleware.WithIssuer(cfg.JWTIssuer), middleware.WithAudience(cfg.JWTAudience)}
+	if cfg.JWKSURL != "" {
+		keySet, err := middleware.NewJWKS(ctx, cfg.JWKSURL, cfg.JWKSRefresh)
+		if err != nil {
+			log.Fatalf("Falha ao carregar o JWKS: %v", err)
+		}
+		authOptions = append(authOptions, middleware.WithJWKS(keySet))
+	} else if cfg.JWTSecretKey == "" {
+		log.Fatal("Configure JWT_SECRET_KEY ou JWT_JWKS_URL para validar os tokens")
+	}
+
+	authMiddleware := middleware.NewAuthMiddleware(cfg.JWTSecretKey, authOptions...)
 
-	authMiddleware := middleware.NewAuthMiddleware(cfg.JWTSecretKey)
+	// Criação de pedidos pode ser repetida por um dia; as demais escritas só
+	// precisam cobrir retentativas imediatas.
+	idempotent := middleware.NewIdempotencyMiddleware(idempotencyRepository, cfg.IdempotencyTTL)
+	idempotentShort := middleware.NewIdempotencyMiddleware(idempotencyRepository, time.Hour)
 
 	router.Use(middleware.PrometheusMiddleware())
 
@@ -70,11 +124,25 @@ func main() {
 	{
 		orders := apiV1.Group("/orders")
 		{
-			orders.POST("/", authMiddleware, orderHandler.CreateOrder)
-			orders.GET("/", authMiddleware, orderHandler.GetOrders)
-			orders.GET("/:id", authMiddleware, orderHandler.GetOrderById)
-			orders.DELETE("/:id", authMiddleware, orderHandler.DeleteOrder)
-			orders.PATCH("/:id", authMiddleware, orderHandler.UpdateOrder)
+			orders.POST("/", authMiddleware, middleware.RequireScope(middleware.ScopeOrdersCreate), idempotent, orderHandler.CreateOrder)
+			orders.GET("/", authMiddleware, middleware.RequireScope(middleware.ScopeOrdersRead), orderHandler.GetOrders)
+			orders.GET("/:id", authMiddleware, middleware.RequireScope(middleware.ScopeOrdersRead), orderHandler.GetOrderById)
+			orders.DELETE("/:id", authMiddleware, middleware.RequireScope(middleware.ScopeOrdersDelete), idempotentShort, orderHandler.DeleteOrder)
+			orders.PATCH("/:id", authMiddleware, middleware.RequireScope(middleware.ScopeOrdersUpdate, middleware.ScopeOrdersUpdateAll, middleware.ScopeOrdersShip, middleware.ScopeOrdersPay), idempotentShort, orderHandler.UpdateOrder)
+		}
+
+		requireAdmin := middleware.RequireScope(middleware.ScopeProductsManage)
+
+		products := apiV1.Group("/products")
+		{
+			products.GET("/", authMiddleware, productHandler.ListProducts)
+			products.GET("/sku/:sku", authMiddleware, productHandler.GetProductBySku)
+			products.POST("/", authMiddleware, requireAdmin, idempotentShort, productHandler.CreateProduct)
+			products.PATCH("/:id", authMiddleware, requireAdmin, idempotentShort, productHandler.UpdateProduct)
+			products.DELETE("/:id", authMiddleware, requireAdmin, idempotentShort, productHandler.DeactivateProduct)
+			products.GET("/:id/price", authMiddleware, productHandler.GetPriceAt)
+			products.GET("/:id/prices", authMiddleware, requireAdmin, productHandler.ListPriceHistory)
+			products.POST("/:id/prices", authMiddleware, requireAdmin, idempotentShort, productHandler.SchedulePriceChange)
 		}
 	}
 
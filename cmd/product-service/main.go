@@ -3,37 +3,45 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net"
 
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mlucas4330/orderflow-pro/internal/config"
+	"github.com/mlucas4330/orderflow-pro/internal/pricing"
+	"github.com/mlucas4330/orderflow-pro/internal/repository"
+	"github.com/mlucas4330/orderflow-pro/internal/server"
 	pb "github.com/mlucas4330/orderflow-pro/pkg/productpb"
 	"google.golang.org/grpc"
 )
 
-type server struct {
-	pb.UnimplementedProductServiceServer
-}
+func main() {
+	ctx := context.Background()
 
-func (s *server) GetProductDetails(ctx context.Context, req *pb.GetProductDetailsRequest) (*pb.GetProductDetailsResponse, error) {
-	productID := req.GetProductId()
-	log.Printf("Recebida requisição para buscar detalhes do produto: %s", productID)
+	cfg := config.LoadProductConfig()
 
-	return &pb.GetProductDetailsResponse{
-		Id:    productID,
-		Name:  "Café Especial Grão Mágico 250g (v_grpc)",
-		Price: "55.90",
-	}, nil
-}
+	postgresDsn := fmt.Sprintf("postgres://%s:%s@%s:5432/%s?sslmode=disable", cfg.PostgresUser, cfg.PostgresPass, cfg.PostgresHost, cfg.PostgresDb)
 
-func main() {
-	listener, err := net.Listen("tcp", ":50051")
+	dbpool, err := pgxpool.New(ctx, postgresDsn)
+	if err != nil {
+		log.Fatalf("Falha ao conectar com o banco de dados: %v", err)
+	}
+	defer dbpool.Close()
+
+	listener, err := net.Listen("tcp", cfg.GRPCAddr)
 	if err != nil {
 		log.Fatalf("Falha ao escutar na porta: %v", err)
 	}
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(server.NewServiceAuthInterceptor(cfg.ServiceToken)))
+
+	productRepository := repository.NewProductRepository(dbpool)
+
+	priceScheduler := pricing.NewScheduler(productRepository, cfg.PriceApplyInterval)
+	go priceScheduler.Run(ctx)
 
-	pb.RegisterProductServiceServer(grpcServer, &server{})
+	pb.RegisterProductServiceServer(grpcServer, server.NewProductServer(productRepository))
 
 	log.Printf("Servidor gRPC escutando em %v", listener.Addr())
 
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE
+  outbox (
+    id BIGSERIAL PRIMARY KEY,
+    aggregate_id UUID NOT NULL,
+    event_type TEXT NOT NULL,
+    destination TEXT NOT NULL CHECK (destination IN ('kafka', 'rabbitmq')),
+    topic TEXT NOT NULL,
+    payload BYTEA NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP
+    WITH
+      TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+      created_at TIMESTAMP
+    WITH
+      TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+      published_at TIMESTAMP
+    WITH
+      TIME ZONE
+  );
+
+CREATE INDEX idx_outbox_pending ON outbox (aggregate_id, id)
+WHERE
+  published_at IS NULL;
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE outbox;
+
+-- +goose StatementEnd
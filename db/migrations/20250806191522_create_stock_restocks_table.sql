@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE
+  stock_restocks (
+    order_id UUID PRIMARY KEY,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP
+    WITH
+      TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+  );
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE stock_restocks;
+
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE
+  processed_events (
+    consumer TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    order_id UUID NOT NULL,
+    processed_at TIMESTAMP
+    WITH
+      TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+      PRIMARY KEY (consumer, event_type, order_id)
+  );
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE processed_events;
+
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE orders
+DROP CONSTRAINT orders_status_check;
+
+ALTER TABLE orders
+ADD CONSTRAINT orders_status_check CHECK (
+  status IN (
+    'pending',
+    'confirmed',
+    'paid',
+    'shipped',
+    'delivered',
+    'cancelled',
+    'refunded'
+  )
+);
+
+ALTER TABLE orders
+ADD COLUMN cancellation_reason TEXT;
+
+ALTER TABLE processed_events
+ADD COLUMN outcome TEXT;
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE processed_events
+DROP COLUMN outcome;
+
+ALTER TABLE orders
+DROP COLUMN cancellation_reason;
+
+ALTER TABLE orders
+DROP CONSTRAINT orders_status_check;
+
+ALTER TABLE orders
+ADD CONSTRAINT orders_status_check CHECK (
+  status IN (
+    'pending',
+    'paid',
+    'shipped',
+    'delivered',
+    'cancelled',
+    'refunded'
+  )
+);
+
+-- +goose StatementEnd
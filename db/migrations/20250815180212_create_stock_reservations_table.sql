@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE
+  stock_reservations (
+    order_id UUID NOT NULL,
+    product_id UUID NOT NULL REFERENCES products (id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'committed', 'released', 'expired')),
+    expires_at TIMESTAMP
+    WITH
+      TIME ZONE NOT NULL,
+      created_at TIMESTAMP
+    WITH
+      TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+      updated_at TIMESTAMP
+    WITH
+      TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+      PRIMARY KEY (order_id, product_id)
+  );
+
+CREATE INDEX idx_stock_reservations_active_product ON stock_reservations (product_id)
+WHERE
+  status = 'active';
+
+CREATE INDEX idx_stock_reservations_active_expires_at ON stock_reservations (expires_at)
+WHERE
+  status = 'active';
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE stock_reservations;
+
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE
+  product_prices (
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    price NUMERIC(10, 2) NOT NULL CHECK (price >= 0),
+    effective_from TIMESTAMP
+    WITH
+      TIME ZONE NOT NULL,
+      created_at TIMESTAMP
+    WITH
+      TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+      PRIMARY KEY (product_id, effective_from)
+  );
+
+INSERT INTO
+  product_prices (product_id, price, effective_from)
+SELECT
+  id,
+  price,
+  created_at
+FROM
+  products;
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE product_prices;
+
+-- +goose StatementEnd
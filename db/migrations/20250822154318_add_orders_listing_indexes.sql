@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX idx_orders_created_at_id ON orders (created_at, id);
+
+CREATE INDEX idx_orders_customer_created_at_id ON orders (customer_id, created_at, id);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_orders_customer_created_at_id;
+
+DROP INDEX idx_orders_created_at_id;
+
+-- +goose StatementEnd
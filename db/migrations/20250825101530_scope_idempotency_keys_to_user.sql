@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE idempotency_keys
+DROP CONSTRAINT idempotency_keys_pkey,
+ADD PRIMARY KEY (user_id, idempotency_key),
+ADD COLUMN request_hash TEXT;
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE idempotency_keys
+DROP COLUMN request_hash,
+DROP CONSTRAINT idempotency_keys_pkey,
+ADD PRIMARY KEY (idempotency_key);
+
+-- +goose StatementEnd
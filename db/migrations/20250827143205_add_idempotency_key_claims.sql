@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE idempotency_keys
+ALTER COLUMN response_status_code
+DROP NOT NULL,
+ADD COLUMN status TEXT NOT NULL DEFAULT 'completed' CHECK (status IN ('processing', 'completed')),
+ADD COLUMN locked_until TIMESTAMP
+WITH
+  TIME ZONE;
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM idempotency_keys
+WHERE
+  status = 'processing';
+
+ALTER TABLE idempotency_keys
+DROP COLUMN locked_until,
+DROP COLUMN status,
+ALTER COLUMN response_status_code
+SET NOT NULL;
+
+-- +goose StatementEnd
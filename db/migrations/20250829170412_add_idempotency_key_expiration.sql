@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE idempotency_keys
+ADD COLUMN response_headers JSONB,
+ADD COLUMN expires_at TIMESTAMP
+WITH
+  TIME ZONE;
+
+UPDATE idempotency_keys
+SET
+  expires_at = created_at + INTERVAL '24 hours'
+WHERE
+  status = 'completed';
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at)
+WHERE
+  status = 'completed';
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_idempotency_keys_expires_at;
+
+ALTER TABLE idempotency_keys
+DROP COLUMN expires_at,
+DROP COLUMN response_headers;
+
+-- +goose StatementEnd
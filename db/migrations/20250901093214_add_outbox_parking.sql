@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE outbox
+ADD COLUMN parked_at TIMESTAMP
+WITH
+  TIME ZONE;
+
+DROP INDEX idx_outbox_pending;
+
+CREATE INDEX idx_outbox_pending ON outbox (aggregate_id, id)
+WHERE
+  published_at IS NULL
+  AND parked_at IS NULL;
+
+CREATE INDEX idx_outbox_parked ON outbox (parked_at)
+WHERE
+  parked_at IS NOT NULL;
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_outbox_parked;
+
+DROP INDEX idx_outbox_pending;
+
+CREATE INDEX idx_outbox_pending ON outbox (aggregate_id, id)
+WHERE
+  published_at IS NULL;
+
+ALTER TABLE outbox
+DROP COLUMN parked_at;
+
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Mensagens estacionadas voltam a segurar as seguintes do mesmo agregado, então
+-- o índice usado nessa checagem precisa incluí-las.
+DROP INDEX idx_outbox_pending;
+
+CREATE INDEX idx_outbox_pending ON outbox (aggregate_id, id)
+WHERE
+  published_at IS NULL;
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_outbox_pending;
+
+CREATE INDEX idx_outbox_pending ON outbox (aggregate_id, id)
+WHERE
+  published_at IS NULL
+  AND parked_at IS NULL;
+
+-- +goose StatementEnd
@@ -0,0 +1,199 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	redis "github.com/redis/go-redis/v9"
+)
+
+var circuitOpen = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "orderflow_cache_circuit_open",
+	Help: "1 quando o circuito do Redis está aberto e o cache está desligado.",
+})
+
+// ErrCircuitOpen é devolvido pelos comandos enquanto o Redis está fora. Quem
+// usa o cache deve tratá-lo como cache miss.
+var ErrCircuitOpen = errors.New("cache indisponível: circuito do Redis aberto")
+
+const (
+	defaultFailureThreshold = 5
+	defaultProbeInterval    = 5 * time.Second
+	probeTimeout            = 2 * time.Second
+)
+
+type probeKey struct{}
+
+// Breaker é um circuit breaker instalado como hook no cliente do Redis. Depois
+// de Threshold falhas de conexão seguidas o circuito abre e os comandos falham
+// na hora, sem esperar o timeout de rede. Enquanto ele estiver aberto, Run
+// testa a conexão a cada ProbeInterval e fecha o circuito quando o Redis volta.
+// As escritas perdidas durante a queda são compensadas pelas funções
+// registradas com OnRecover.
+type Breaker struct {
+	Client        redis.UniversalClient
+	Threshold     int
+	ProbeInterval time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	open      bool
+	onRecover []func(context.Context) error
+}
+
+func NewBreaker(client redis.UniversalClient) *Breaker {
+	breaker := &Breaker{Client: client, Threshold: defaultFailureThreshold, ProbeInterval: defaultProbeInterval}
+	client.AddHook(breaker)
+	return breaker
+}
+
+// Available diz se o circuito está fechado, ou seja, se o cache está em uso.
+func (b *Breaker) Available() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return !b.open
+}
+
+// Trip abre o circuito na hora, por exemplo quando o Redis já está fora na
+// inicialização.
+func (b *Breaker) Trip() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trip()
+}
+
+// OnRecover registra uma função chamada quando o Redis volta, antes de o
+// circuito fechar. Se alguma delas falhar, o circuito continua aberto e a
+// recuperação é tentada de novo no próximo teste de conexão.
+func (b *Breaker) OnRecover(fn func(context.Context) error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.onRecover = append(b.onRecover, fn)
+}
+
+// Run reconecta ao Redis em segundo plano até ctx ser cancelado.
+func (b *Breaker) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.ProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !b.Available() {
+				b.probe(ctx)
+			}
+		}
+	}
+}
+
+func (b *Breaker) probe(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(context.WithValue(ctx, probeKey{}, true), probeTimeout)
+	defer cancel()
+
+	if err := b.Client.Ping(ctx).Err(); err != nil {
+		return
+	}
+
+	b.mu.Lock()
+	hooks := b.onRecover
+	b.mu.Unlock()
+
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			log.Printf("AVISO: Falha ao recuperar o cache depois da queda do Redis: %v", err)
+			return
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.open = false
+	b.failures = 0
+	circuitOpen.Set(0)
+	log.Println("Conexão com o Redis restabelecida, cache reativado")
+}
+
+func (b *Breaker) allow(ctx context.Context) bool {
+	if probe, _ := ctx.Value(probeKey{}).(bool); probe {
+		return true
+	}
+	return b.Available()
+}
+
+// record conta só falhas de conexão. Respostas do Redis, como redis.Nil ou um
+// erro de comando, mostram que ele está de pé; cancelamentos são do chamador.
+func (b *Breaker) record(err error) {
+	var redisErr redis.Error
+	failed := err != nil && !errors.As(err, &redisErr) && !errors.Is(err, context.Canceled)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if !b.open && b.failures >= b.Threshold {
+		b.trip()
+	}
+}
+
+func (b *Breaker) trip() {
+	if b.open {
+		return
+	}
+	b.open = true
+	circuitOpen.Set(1)
+	log.Printf("AVISO: Redis indisponível, cache desligado até a conexão voltar")
+}
+
+// DialHook não faz nada: as falhas de conexão já chegam a ProcessHook pelo
+// erro do comando. Ele também não roda para os nós do Redis Cluster, cujos
+// clientes não recebem os hooks do ClusterClient.
+func (b *Breaker) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (b *Breaker) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if !b.allow(ctx) {
+			cmd.SetErr(ErrCircuitOpen)
+			return ErrCircuitOpen
+		}
+
+		err := next(ctx, cmd)
+		b.record(err)
+		return err
+	}
+}
+
+func (b *Breaker) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if !b.allow(ctx) {
+			for _, cmd := range cmds {
+				cmd.SetErr(ErrCircuitOpen)
+			}
+			return ErrCircuitOpen
+		}
+
+		err := next(ctx, cmds)
+		b.record(err)
+		return err
+	}
+}
@@ -0,0 +1,179 @@
+package cache_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mlucas4330/orderflow-pro/internal/cache"
+	"github.com/mlucas4330/orderflow-pro/internal/config"
+	redis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// freeAddr devolve um endereço local em que nada está escutando.
+func freeAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+	return addr
+}
+
+// fakeRedis responde PONG ao PING, 1 aos comandos de invalidação e erro a
+// qualquer outro comando, o suficiente para o cliente considerar o Redis de pé.
+// Os comandos recebidos ficam guardados, sem o PING.
+type fakeRedis struct {
+	mu       sync.Mutex
+	commands []string
+}
+
+func (f *fakeRedis) Commands() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return slices.Clone(f.commands)
+}
+
+func serveFakeRedis(t *testing.T, addr string) *fakeRedis {
+	listener, err := net.Listen("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	fake := &fakeRedis{}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					header, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					var args int
+					if _, err := fmt.Sscanf(header, "*%d", &args); err != nil {
+						return
+					}
+					var parts []string
+					for i := range args * 2 {
+						line, err := reader.ReadString('\n')
+						if err != nil {
+							return
+						}
+						if i%2 == 1 {
+							parts = append(parts, strings.TrimSpace(line))
+						}
+					}
+					command := strings.ToUpper(parts[0])
+					reply := "-ERR unknown command\r\n"
+					switch command {
+					case "PING":
+						reply = "+PONG\r\n"
+					case "DEL", "INCR", "EXPIRE", "PUBLISH":
+						reply = ":1\r\n"
+					}
+					if command != "PING" {
+						fake.mu.Lock()
+						fake.commands = append(fake.commands, strings.Join(parts, " "))
+						fake.mu.Unlock()
+					}
+					conn.Write([]byte(reply))
+				}
+			}()
+		}
+	}()
+
+	return fake
+}
+
+func TestBreakerOpensAfterRepeatedFailures(t *testing.T) {
+	ctx := context.Background()
+	client := redis.NewClient(&redis.Options{Addr: freeAddr(t), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+
+	breaker := cache.NewBreaker(client)
+	breaker.Threshold = 2
+
+	for range 2 {
+		err := client.Get(ctx, "chave").Err()
+		require.Error(t, err)
+		require.NotErrorIs(t, err, cache.ErrCircuitOpen)
+	}
+
+	require.False(t, breaker.Available())
+	require.ErrorIs(t, client.Get(ctx, "chave").Err(), cache.ErrCircuitOpen, "Com o circuito aberto o comando deveria falhar sem ir ao Redis")
+
+	pipe := client.TxPipeline()
+	pipe.Incr(ctx, "versao")
+	_, err := pipe.Exec(ctx)
+	require.ErrorIs(t, err, cache.ErrCircuitOpen)
+}
+
+func TestBreakerReconnectsInBackground(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	addr := freeAddr(t)
+	client, breaker, err := cache.NewRedisClient(ctx, config.RedisConfig{Addrs: []string{addr}})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	require.False(t, breaker.Available(), "Sem Redis o circuito deveria começar aberto")
+	require.ErrorIs(t, client.Ping(ctx).Err(), cache.ErrCircuitOpen)
+
+	breaker.ProbeInterval = 20 * time.Millisecond
+	go breaker.Run(ctx)
+
+	serveFakeRedis(t, addr)
+
+	require.Eventually(t, breaker.Available, 2*time.Second, 20*time.Millisecond, "O breaker deveria reconectar quando o Redis voltar")
+	require.NoError(t, client.Ping(ctx).Err())
+}
+
+func TestInvalidationsDuringOutageAreReplayedOnRecovery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	addr := freeAddr(t)
+	client, breaker, err := cache.NewRedisClient(ctx, config.RedisConfig{Addrs: []string{addr}})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	c := cache.New(client)
+	c.Local = cache.NewLocalCache(10, time.Minute)
+	c.Local.Set("order:1", "antigo")
+	breaker.OnRecover(c.Recover)
+
+	require.ErrorIs(t, c.Delete(ctx, "order:1"), cache.ErrCircuitOpen)
+	_, ok := c.Local.Get("order:1")
+	require.False(t, ok, "A invalidação deveria valer na memória mesmo com o Redis fora")
+
+	breaker.ProbeInterval = 20 * time.Millisecond
+	go breaker.Run(ctx)
+	fake := serveFakeRedis(t, addr)
+
+	require.Eventually(t, breaker.Available, 2*time.Second, 20*time.Millisecond)
+	require.Contains(t, fake.Commands(), "del order:1", "A invalidação perdida deveria ser reenviada antes de o circuito fechar")
+	require.Contains(t, fake.Commands(), "publish "+cache.InvalidationChannel+" order:1")
+
+	require.NoError(t, c.Delete(ctx, "order:2"))
+	deletes := 0
+	for _, command := range fake.Commands() {
+		if command == "del order:1" {
+			deletes++
+		}
+	}
+	require.Equal(t, 1, deletes, "A invalidação pendente deveria ser reenviada uma única vez")
+}
@@ -0,0 +1,343 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	redis "github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	cacheHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "orderflow_cache_hits_total",
+		Help: "Leituras atendidas pelo cache, por camada.",
+	}, []string{"tier"})
+	cacheMisses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "orderflow_cache_misses_total",
+		Help: "Leituras que não encontraram o valor no cache, por camada.",
+	}, []string{"tier"})
+	cacheEvictions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "orderflow_cache_evictions_total",
+		Help: "Valores removidos do cache, por camada e motivo.",
+	}, []string{"tier", "reason"})
+)
+
+// InvalidationChannel é o canal do Redis em que as chaves invalidadas são
+// publicadas, para que cada réplica as descarte do seu cache em memória.
+const InvalidationChannel = "cache:invalidations"
+
+const (
+	tierLocal = "local"
+	tierRedis = "redis"
+
+	defaultBeta       = 1.0
+	refreshLockTTL    = 10 * time.Second
+	backgroundTimeout = 10 * time.Second
+	// generationTTL só precisa cobrir a duração de uma carga.
+	generationTTL = time.Hour
+)
+
+// storeIfCurrent grava o valor só se a geração da chave não mudou desde o
+// início da carga, ou seja, se ela não foi invalidada nesse meio-tempo.
+var storeIfCurrent = redis.NewScript(`
+if (redis.call('GET', KEYS[2]) or '0') ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1
+`)
+
+// Policy define por quanto tempo um valor é servido como fresco (TTL) e por
+// quanto tempo depois disso ele ainda pode ser servido enquanto é recarregado
+// em segundo plano (StaleTTL). Local indica que o valor também pode ser
+// guardado no cache em memória, quando ele estiver habilitado.
+type Policy struct {
+	TTL      time.Duration
+	StaleTTL time.Duration
+	Local    bool
+}
+
+// Cache é uma camada read-through sobre o Redis. Leituras concorrentes da mesma
+// chave no mesmo processo são agrupadas numa única carga (singleflight), e os
+// valores são renovados antes de expirar com probabilidade crescente (XFetch),
+// sem bloquear quem está lendo.
+type Cache struct {
+	Redis redis.UniversalClient
+	// Local é opcional e fica na frente do Redis para as políticas com Local.
+	Local *LocalCache
+	// Beta ajusta a antecipação do XFetch; valores maiores renovam mais cedo.
+	Beta float64
+
+	group singleflight.Group
+
+	// pending guarda as chaves cuja invalidação falhou, para reenviá-las.
+	mu      sync.Mutex
+	pending map[string]struct{}
+}
+
+func New(client redis.UniversalClient) *Cache {
+	return &Cache{Redis: client, Beta: defaultBeta}
+}
+
+// loaded é o resultado de uma carga. Current é false quando a chave foi
+// invalidada durante a carga (ou a geração não pôde ser lida): o valor ainda
+// serve para quem pediu, mas não vai para nenhum cache.
+type loaded struct {
+	Value   any
+	Current bool
+}
+
+// entry é o formato gravado no Redis: o valor, quanto tempo a carga levou e até
+// quando ele é considerado fresco.
+type entry struct {
+	Value      json.RawMessage `json:"v"`
+	Delta      time.Duration   `json:"d"`
+	FreshUntil time.Time       `json:"f"`
+}
+
+// Fetch devolve o valor da chave ou o carrega com load. Um valor fresco é
+// devolvido direto; um valor vencido, mas ainda dentro de StaleTTL, também é
+// devolvido enquanto uma única requisição o recarrega em segundo plano. Erros
+// do Redis são tratados como cache miss. Erros de load não são guardados.
+func Fetch[T any](ctx context.Context, c *Cache, key string, policy Policy, load func(context.Context) (T, error)) (T, error) {
+	local := policy.Local && c.Local != nil
+	if local {
+		if value, ok := c.Local.Get(key); ok {
+			cacheHits.WithLabelValues(tierLocal).Inc()
+			return value.(T), nil
+		}
+		cacheMisses.WithLabelValues(tierLocal).Inc()
+	}
+
+	cached, err := c.get(ctx, key)
+	if err == nil {
+		var value T
+		if err := json.Unmarshal(cached.Value, &value); err == nil {
+			cacheHits.WithLabelValues(tierRedis).Inc()
+			if c.shouldRefresh(cached) {
+				go c.refresh(context.WithoutCancel(ctx), key, policy, func(ctx context.Context) (any, error) { return load(ctx) })
+			}
+			// Um valor vencido não vai para a memória, senão continuaria sendo
+			// servido depois de renovado no Redis.
+			if local && time.Now().Before(cached.FreshUntil) {
+				c.Local.Set(key, value)
+			}
+			return value, nil
+		}
+		log.Printf("Valor inválido no cache para a chave %s, recarregando: %v", key, err)
+	} else if !errors.Is(err, redis.Nil) && !errors.Is(err, ErrCircuitOpen) {
+		log.Printf("Erro ao buscar do Redis, mas não é um cache miss: %v", err)
+	}
+	cacheMisses.WithLabelValues(tierRedis).Inc()
+
+	// A carga é compartilhada entre as requisições agrupadas, então não pode
+	// ser cancelada só porque a primeira delas desistiu.
+	result, err, _ := c.group.Do(key, func() (any, error) {
+		return c.loadAndStore(context.WithoutCancel(ctx), key, policy, func(ctx context.Context) (any, error) { return load(ctx) })
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	loaded := result.(loaded)
+	if local && loaded.Current {
+		c.Local.Set(key, loaded.Value)
+	}
+
+	return loaded.Value.(T), nil
+}
+
+// Delete remove as chaves do Redis e da memória, e avisa as outras réplicas
+// para que também as descartem. A geração de cada chave avança, para que uma
+// carga iniciada antes não grave o valor antigo de volta. Se o Redis falhar, as
+// chaves ficam pendentes e são reenviadas no próximo Delete ou em Recover.
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	keys = c.takePending(keys)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// Comandos por chave, porque no Redis Cluster as chaves podem estar em
+	// slots diferentes.
+	pipe := c.Redis.Pipeline()
+	for _, key := range keys {
+		pipe.Incr(ctx, generationKey(key))
+		pipe.Expire(ctx, generationKey(key), generationTTL)
+		pipe.Del(ctx, key)
+		pipe.Publish(ctx, InvalidationChannel, key)
+	}
+	_, err := pipe.Exec(ctx)
+
+	if c.Local != nil {
+		c.Local.Delete(keys...)
+	}
+	if err != nil {
+		c.addPending(keys)
+		return err
+	}
+
+	cacheEvictions.WithLabelValues(tierRedis, "invalidated").Add(float64(len(keys)))
+	return nil
+}
+
+// Recover reenvia as invalidações que falharam enquanto o Redis estava fora.
+// Deve ser registrado no breaker com OnRecover.
+func (c *Cache) Recover(ctx context.Context) error {
+	return c.Delete(ctx)
+}
+
+// takePending junta às chaves informadas as que ficaram pendentes.
+func (c *Cache) takePending(keys []string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.pending {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	clear(c.pending)
+	return keys
+}
+
+func (c *Cache) addPending(keys []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.pending == nil {
+		c.pending = make(map[string]struct{})
+	}
+	for _, key := range keys {
+		c.pending[key] = struct{}{}
+	}
+}
+
+// ListenInvalidations descarta da memória as chaves invalidadas por outras
+// réplicas, até ctx ser cancelado. A assinatura não passa pelo breaker: o
+// go-redis reconecta sozinho quando o Redis volta. Mensagens perdidas enquanto
+// a conexão cai ficam limitadas pelo TTL do cache em memória.
+func (c *Cache) ListenInvalidations(ctx context.Context) {
+	if c.Local == nil {
+		return
+	}
+
+	pubsub := c.Redis.Subscribe(ctx, InvalidationChannel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			c.Local.Delete(message.Payload)
+		}
+	}
+}
+
+// generationKey usa a chave como hash tag, para que ela fique no mesmo slot da
+// chave no Redis Cluster e o script possa ler as duas.
+func generationKey(key string) string {
+	return "{" + key + "}:gen"
+}
+
+func (c *Cache) get(ctx context.Context, key string) (*entry, error) {
+	data, err := c.Redis.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var cached entry
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, err
+	}
+
+	return &cached, nil
+}
+
+// shouldRefresh implementa o XFetch: a chance de renovar cresce conforme o
+// valor se aproxima de FreshUntil e é maior para valores caros de carregar.
+// Depois de FreshUntil a renovação é sempre disparada.
+func (c *Cache) shouldRefresh(cached *entry) bool {
+	early := time.Duration(float64(cached.Delta) * c.Beta * -math.Log(1-rand.Float64()))
+	return !time.Now().Add(early).Before(cached.FreshUntil)
+}
+
+// refresh recarrega a chave em segundo plano. O lock no Redis evita que várias
+// réplicas recarreguem o mesmo valor ao mesmo tempo.
+func (c *Cache) refresh(ctx context.Context, key string, policy Policy, load func(context.Context) (any, error)) {
+	ctx, cancel := context.WithTimeout(ctx, backgroundTimeout)
+	defer cancel()
+
+	acquired, err := c.Redis.SetNX(ctx, key+":refresh", 1, refreshLockTTL).Result()
+	if err != nil || !acquired {
+		return
+	}
+	defer c.Redis.Del(ctx, key+":refresh")
+
+	_, err, _ = c.group.Do(key, func() (any, error) {
+		return c.loadAndStore(ctx, key, policy, load)
+	})
+	if err != nil {
+		log.Printf("Erro ao renovar a chave %s do cache em segundo plano: %v", key, err)
+	}
+}
+
+// loadAndStore carrega o valor e o grava no Redis, a menos que a chave tenha
+// sido invalidada durante a carga.
+func (c *Cache) loadAndStore(ctx context.Context, key string, policy Policy, load func(context.Context) (any, error)) (loaded, error) {
+	generation, err := c.Redis.Get(ctx, generationKey(key)).Result()
+	if errors.Is(err, redis.Nil) {
+		generation, err = "0", nil
+	}
+
+	start := time.Now()
+	value, loadErr := load(ctx)
+	if loadErr != nil {
+		return loaded{}, loadErr
+	}
+	delta := time.Since(start)
+
+	// Sem a geração não dá para saber se a chave foi invalidada durante a
+	// carga, então o valor não é guardado.
+	if err != nil {
+		return loaded{Value: value}, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("Falha ao serializar a chave %s para o cache: %v", key, err)
+		return loaded{Value: value}, nil
+	}
+
+	payload, err := json.Marshal(entry{Value: data, Delta: delta, FreshUntil: time.Now().Add(policy.TTL)})
+	if err != nil {
+		log.Printf("Falha ao serializar a chave %s para o cache: %v", key, err)
+		return loaded{Value: value}, nil
+	}
+
+	ttl := (policy.TTL + policy.StaleTTL).Milliseconds()
+	stored, err := storeIfCurrent.Run(ctx, c.Redis, []string{key, generationKey(key)}, generation, payload, ttl).Int()
+	if err != nil {
+		if !errors.Is(err, ErrCircuitOpen) {
+			log.Printf("Falha ao salvar a chave %s no cache do Redis: %v", key, err)
+		}
+		return loaded{Value: value}, nil
+	}
+
+	return loaded{Value: value, Current: stored == 1}, nil
+}
@@ -0,0 +1,118 @@
+package cache_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/mlucas4330/orderflow-pro/internal/cache"
+	"github.com/mlucas4330/orderflow-pro/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+// setupCache conecta um Cache ao Redis em memória do endereço informado, de
+// modo que os testes não dependem de um Redis de pé.
+func setupCache(t *testing.T, addr string) *cache.Cache {
+	redisClient, _, err := cache.NewRedisClient(context.Background(), config.RedisConfig{Addrs: []string{addr}})
+	require.NoError(t, err)
+	t.Cleanup(func() { redisClient.Close() })
+
+	return cache.New(redisClient)
+}
+
+func TestFetchCoalescesConcurrentLoads(t *testing.T) {
+	c := setupCache(t, miniredis.RunT(t).Addr())
+	ctx := context.Background()
+	key := "test:" + uuid.NewString()
+
+	var loads atomic.Int32
+	load := func(ctx context.Context) (int, error) {
+		loads.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := cache.Fetch(ctx, c, key, cache.Policy{TTL: time.Minute}, load)
+			require.NoError(t, err)
+			require.Equal(t, 42, value)
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, int32(1), loads.Load(), "Leituras concorrentes da mesma chave deveriam carregar o valor uma única vez")
+
+	value, err := cache.Fetch(ctx, c, key, cache.Policy{TTL: time.Minute}, load)
+	require.NoError(t, err)
+	require.Equal(t, 42, value)
+	require.Equal(t, int32(1), loads.Load(), "O valor fresco deveria vir do cache")
+}
+
+func TestFetchServesStaleWhileRevalidating(t *testing.T) {
+	c := setupCache(t, miniredis.RunT(t).Addr())
+	ctx := context.Background()
+	key := "test:" + uuid.NewString()
+
+	policy := cache.Policy{TTL: 50 * time.Millisecond, StaleTTL: time.Minute}
+	var version atomic.Int32
+	load := func(ctx context.Context) (int32, error) {
+		return version.Add(1), nil
+	}
+
+	value, err := cache.Fetch(ctx, c, key, policy, load)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), value)
+
+	time.Sleep(100 * time.Millisecond)
+
+	value, err = cache.Fetch(ctx, c, key, policy, load)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), value, "O valor vencido deveria ser servido enquanto é recarregado")
+
+	require.Eventually(t, func() bool {
+		value, err := cache.Fetch(ctx, c, key, cache.Policy{TTL: time.Minute}, load)
+		return err == nil && value >= 2
+	}, 2*time.Second, 20*time.Millisecond, "O valor deveria ser renovado em segundo plano")
+}
+
+func TestFetchDoesNotStoreValueLoadedBeforeInvalidation(t *testing.T) {
+	c := setupCache(t, miniredis.RunT(t).Addr())
+	ctx := context.Background()
+	key := "test:" + uuid.NewString()
+
+	loading := make(chan struct{})
+	release := make(chan struct{})
+	var version atomic.Int32
+	load := func(ctx context.Context) (int32, error) {
+		current := version.Add(1)
+		if current == 1 {
+			close(loading)
+			<-release
+		}
+		return current, nil
+	}
+
+	done := make(chan int32)
+	go func() {
+		value, err := cache.Fetch(ctx, c, key, cache.Policy{TTL: time.Minute}, load)
+		require.NoError(t, err)
+		done <- value
+	}()
+
+	<-loading
+	require.NoError(t, c.Delete(ctx, key))
+	close(release)
+	require.Equal(t, int32(1), <-done, "Quem pediu durante a carga deveria receber o valor carregado")
+
+	value, err := cache.Fetch(ctx, c, key, cache.Policy{TTL: time.Minute}, load)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), value, "O valor carregado antes da invalidação não deveria ter sido gravado")
+}
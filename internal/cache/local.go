@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LocalCache é um LRU em memória, limitado a Size entradas, em que cada valor
+// vale por TTL. Os valores são guardados já decodificados e compartilhados
+// entre quem os lê, então não devem ser alterados.
+type LocalCache struct {
+	Size int
+	TTL  time.Duration
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type localEntry struct {
+	key       string
+	value     any
+	expiresAt time.Time
+}
+
+func NewLocalCache(size int, ttl time.Duration) *LocalCache {
+	return &LocalCache{
+		Size:    size,
+		TTL:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (l *LocalCache) Get(key string) (any, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	cached := element.Value.(*localEntry)
+	if time.Now().After(cached.expiresAt) {
+		l.remove(element, "expired")
+		return nil, false
+	}
+
+	l.order.MoveToFront(element)
+	return cached.value, true
+}
+
+func (l *LocalCache) Set(key string, value any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	expiresAt := time.Now().Add(l.TTL)
+	if element, ok := l.entries[key]; ok {
+		cached := element.Value.(*localEntry)
+		cached.value, cached.expiresAt = value, expiresAt
+		l.order.MoveToFront(element)
+		return
+	}
+
+	l.entries[key] = l.order.PushFront(&localEntry{key: key, value: value, expiresAt: expiresAt})
+	for l.order.Len() > l.Size {
+		l.remove(l.order.Back(), "capacity")
+	}
+}
+
+func (l *LocalCache) Delete(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := l.entries[key]; ok {
+			l.remove(element, "invalidated")
+		}
+	}
+}
+
+func (l *LocalCache) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.order.Len()
+}
+
+func (l *LocalCache) remove(element *list.Element, reason string) {
+	l.order.Remove(element)
+	delete(l.entries, element.Value.(*localEntry).key)
+	cacheEvictions.WithLabelValues(tierLocal, reason).Inc()
+}
@@ -0,0 +1,82 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/mlucas4330/orderflow-pro/internal/cache"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	local := cache.NewLocalCache(2, time.Minute)
+
+	local.Set("a", 1)
+	local.Set("b", 2)
+	_, ok := local.Get("a")
+	require.True(t, ok)
+
+	local.Set("c", 3)
+	require.Equal(t, 2, local.Len())
+
+	_, ok = local.Get("b")
+	require.False(t, ok, "A entrada menos usada deveria ter sido descartada")
+	value, ok := local.Get("a")
+	require.True(t, ok)
+	require.Equal(t, 1, value)
+
+	local.Delete("a")
+	_, ok = local.Get("a")
+	require.False(t, ok)
+}
+
+func TestLocalCacheExpires(t *testing.T) {
+	local := cache.NewLocalCache(10, 20*time.Millisecond)
+
+	local.Set("a", 1)
+	time.Sleep(40 * time.Millisecond)
+
+	_, ok := local.Get("a")
+	require.False(t, ok, "A entrada deveria expirar depois do TTL")
+	require.Zero(t, local.Len())
+}
+
+func TestDeleteEvictsLocalCacheOnOtherReplicas(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	server := miniredis.RunT(t)
+	writer, reader := setupCache(t, server.Addr()), setupCache(t, server.Addr())
+	writer.Local = cache.NewLocalCache(10, time.Minute)
+	reader.Local = cache.NewLocalCache(10, time.Minute)
+	go reader.ListenInvalidations(ctx)
+
+	key := "test:" + uuid.NewString()
+
+	policy := cache.Policy{TTL: time.Minute, Local: true}
+	loads := 0
+	load := func(ctx context.Context) (int, error) {
+		loads++
+		return loads, nil
+	}
+
+	value, err := cache.Fetch(ctx, reader, key, policy, load)
+	require.NoError(t, err)
+	require.Equal(t, 1, value)
+	require.Equal(t, 1, reader.Local.Len())
+
+	value, err = cache.Fetch(ctx, reader, key, policy, load)
+	require.NoError(t, err)
+	require.Equal(t, 1, value)
+	require.Equal(t, 1, loads, "A segunda leitura deveria vir da memória")
+
+	// A inscrição no canal é assíncrona, então a invalidação é repetida até
+	// chegar à outra réplica.
+	require.Eventually(t, func() bool {
+		require.NoError(t, writer.Delete(ctx, key))
+		return reader.Local.Len() == 0
+	}, 2*time.Second, 50*time.Millisecond, "A invalidação deveria descartar o valor da memória das outras réplicas")
+}
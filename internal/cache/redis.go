@@ -2,21 +2,111 @@ package cache
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"log"
+	"os"
 
+	"github.com/mlucas4330/orderflow-pro/internal/config"
 	redis "github.com/redis/go-redis/v9"
 )
 
-func NewRedisClient(ctx context.Context, redisAddr string, redisDB int) (*redis.Client, error) {
-	rdb := redis.NewClient(&redis.Options{
-		Addr: redisAddr,
-		DB:   redisDB,
-	})
+const (
+	RedisStandalone = "standalone"
+	RedisSentinel   = "sentinel"
+	RedisCluster    = "cluster"
+)
+
+// NewRedisClient cria o cliente do Redis para a topologia configurada,
+// protegido por um circuit breaker. O Redis não é obrigatório: se ele estiver
+// fora, o circuito já começa aberto e o serviço segue sem cache até o breaker
+// reconectar. Só uma configuração inválida devolve erro.
+//
+// No modo cluster o breaker fica no ClusterClient, e não nos clientes de cada
+// nó: os comandos passam por ele antes de serem roteados, então as falhas de
+// qualquer nó contam para o mesmo circuito.
+func NewRedisClient(ctx context.Context, cfg config.RedisConfig) (redis.UniversalClient, *Breaker, error) {
+	if len(cfg.Addrs) == 0 {
+		return nil, nil, errors.New("nenhum endereço do Redis configurado")
+	}
+
+	opts := &redis.UniversalOptions{
+		Addrs:            cfg.Addrs,
+		DB:               cfg.DB,
+		MasterName:       cfg.MasterName,
+		Username:         cfg.Username,
+		Password:         cfg.Password,
+		SentinelUsername: cfg.SentinelUsername,
+		SentinelPassword: cfg.SentinelPassword,
+		PoolSize:         cfg.PoolSize,
+		MinIdleConns:     cfg.MinIdleConns,
+		DialTimeout:      cfg.DialTimeout,
+		ReadTimeout:      cfg.ReadTimeout,
+		WriteTimeout:     cfg.WriteTimeout,
+		PoolTimeout:      cfg.PoolTimeout,
+	}
+
+	// Sentinelas e nós do cluster têm cada um o seu nome; sem ServerName, o
+	// TLS confere o certificado pelo host de cada endereço.
+	if cfg.TLSServerName != "" && cfg.Mode != "" && cfg.Mode != RedisStandalone {
+		return nil, nil, errors.New("REDIS_TLS_SERVER_NAME só vale no modo standalone")
+	}
+
+	if cfg.TLS {
+		tlsConfig, err := redisTLSConfig(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		opts.TLSConfig = tlsConfig
+	}
+
+	var rdb redis.UniversalClient
+	switch cfg.Mode {
+	case "", RedisStandalone:
+		rdb = redis.NewClient(opts.Simple())
+	case RedisSentinel:
+		if cfg.MasterName == "" {
+			return nil, nil, errors.New("REDIS_MASTER_NAME é obrigatório no modo sentinel")
+		}
+		rdb = redis.NewFailoverClient(opts.Failover())
+	case RedisCluster:
+		if cfg.DB != 0 {
+			return nil, nil, errors.New("o Redis Cluster só aceita o banco 0")
+		}
+		rdb = redis.NewClusterClient(opts.Cluster())
+	default:
+		return nil, nil, fmt.Errorf("modo do Redis desconhecido: %q", cfg.Mode)
+	}
+
+	breaker := NewBreaker(rdb)
+
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		log.Printf("Não foi possível conectar ao Redis: %v", err)
+		breaker.Trip()
+	}
+
+	return rdb, breaker, nil
+}
+
+func redisTLSConfig(cfg config.RedisConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.TLSServerName,
+	}
 
-	_, err := rdb.Ping(ctx).Result()
-	if err != nil {
-		return nil, fmt.Errorf("não foi possível conectar ao Redis: %w", err)
+	if cfg.TLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler o CA do Redis: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("nenhum certificado válido em %s", cfg.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
 	}
 
-	return rdb, nil
+	return tlsConfig, nil
 }
@@ -0,0 +1,146 @@
+package cache_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mlucas4330/orderflow-pro/internal/cache"
+	"github.com/mlucas4330/orderflow-pro/internal/config"
+	redis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCA grava um certificado autoassinado em PEM e devolve o caminho.
+func writeCA(t *testing.T) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "orderflow-redis-ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	return path
+}
+
+func TestNewRedisClientStandalone(t *testing.T) {
+	ctx := context.Background()
+	addrs := []string{freeAddr(t), freeAddr(t)}
+
+	client, breaker, err := cache.NewRedisClient(ctx, config.RedisConfig{
+		Addrs:         addrs,
+		DB:            2,
+		Username:      "orderflow",
+		Password:      "segredo",
+		TLS:           true,
+		TLSCAFile:     writeCA(t),
+		TLSServerName: "redis.orderflow.local",
+		PoolSize:      7,
+		DialTimeout:   time.Second,
+		ReadTimeout:   2 * time.Second,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	require.False(t, breaker.Available(), "Sem Redis de pé o circuito deveria começar aberto")
+
+	standalone, ok := client.(*redis.Client)
+	require.True(t, ok)
+	opts := standalone.Options()
+	require.Equal(t, addrs[0], opts.Addr, "O modo standalone deveria usar o primeiro endereço")
+	require.Equal(t, 2, opts.DB)
+	require.Equal(t, "orderflow", opts.Username)
+	require.Equal(t, "segredo", opts.Password)
+	require.Equal(t, 7, opts.PoolSize)
+	require.Equal(t, time.Second, opts.DialTimeout)
+	require.Equal(t, 2*time.Second, opts.ReadTimeout)
+	require.NotNil(t, opts.TLSConfig)
+	require.Equal(t, "redis.orderflow.local", opts.TLSConfig.ServerName)
+	require.Equal(t, uint16(tls.VersionTLS12), opts.TLSConfig.MinVersion)
+	require.NotNil(t, opts.TLSConfig.RootCAs, "O CA informado deveria ser usado para validar o servidor")
+}
+
+func TestNewRedisClientSentinelAndCluster(t *testing.T) {
+	ctx := context.Background()
+	addrs := []string{freeAddr(t), freeAddr(t)}
+
+	client, breaker, err := cache.NewRedisClient(ctx, config.RedisConfig{
+		Mode:       cache.RedisSentinel,
+		Addrs:      addrs,
+		MasterName: "mymaster",
+		DB:         1,
+		Password:   "segredo",
+		TLS:        true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	require.False(t, breaker.Available(), "Sem sentinelas de pé o circuito deveria começar aberto")
+
+	failover, ok := client.(*redis.Client)
+	require.True(t, ok)
+	require.Equal(t, 1, failover.Options().DB)
+	require.Equal(t, "segredo", failover.Options().Password)
+	require.Empty(t, failover.Options().TLSConfig.ServerName, "Cada sentinela deveria ser validada pelo próprio host")
+
+	client, breaker, err = cache.NewRedisClient(ctx, config.RedisConfig{Mode: cache.RedisCluster, Addrs: addrs, TLS: true, PoolSize: 3})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	cluster, ok := client.(*redis.ClusterClient)
+	require.True(t, ok)
+	require.Equal(t, addrs, cluster.Options().Addrs, "Todos os endereços deveriam ser nós iniciais do cluster")
+	require.Equal(t, 3, cluster.Options().PoolSize)
+	require.Empty(t, cluster.Options().TLSConfig.ServerName, "Cada nó do cluster deveria ser validado pelo próprio host")
+
+	require.False(t, breaker.Available())
+	require.ErrorIs(t, client.Get(ctx, "chave").Err(), cache.ErrCircuitOpen, "Os comandos do cluster deveriam passar pelo breaker")
+}
+
+func TestNewRedisClientRejectsInvalidConfig(t *testing.T) {
+	ctx := context.Background()
+	addrs := []string{freeAddr(t), freeAddr(t)}
+
+	_, _, err := cache.NewRedisClient(ctx, config.RedisConfig{})
+	require.Error(t, err, "Sem endereço não há como conectar")
+
+	_, _, err = cache.NewRedisClient(ctx, config.RedisConfig{Mode: cache.RedisSentinel, Addrs: addrs})
+	require.Error(t, err, "O modo sentinel exige o nome do master")
+
+	_, _, err = cache.NewRedisClient(ctx, config.RedisConfig{Mode: cache.RedisCluster, Addrs: addrs, DB: 1})
+	require.Error(t, err, "O Redis Cluster não tem outros bancos")
+
+	_, _, err = cache.NewRedisClient(ctx, config.RedisConfig{Mode: cache.RedisCluster, Addrs: addrs, TLS: true, TLSServerName: "redis.orderflow.local"})
+	require.Error(t, err, "Um único nome de servidor não vale para todos os nós do cluster")
+
+	_, _, err = cache.NewRedisClient(ctx, config.RedisConfig{Mode: cache.RedisSentinel, Addrs: addrs, MasterName: "mymaster", TLS: true, TLSServerName: "redis.orderflow.local"})
+	require.Error(t, err, "Um único nome de servidor não vale para todas as sentinelas")
+
+	_, _, err = cache.NewRedisClient(ctx, config.RedisConfig{Mode: "replicado", Addrs: addrs})
+	require.Error(t, err)
+
+	_, _, err = cache.NewRedisClient(ctx, config.RedisConfig{Addrs: addrs, TLS: true, TLSCAFile: "/nao/existe.pem"})
+	require.Error(t, err)
+
+	invalid := filepath.Join(t.TempDir(), "invalido.pem")
+	require.NoError(t, os.WriteFile(invalid, []byte("não é um certificado"), 0o600))
+	_, _, err = cache.NewRedisClient(ctx, config.RedisConfig{Addrs: addrs, TLS: true, TLSCAFile: invalid})
+	require.Error(t, err)
+}
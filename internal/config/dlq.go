@@ -0,0 +1,20 @@
+package config
+
+import (
+	"log"
+
+	env "github.com/caarlos0/env/v10"
+)
+
+type DLQConfig struct {
+	KafkaBrokers string `env:"KAFKA_BROKERS,required"`
+	SourceTopic  string `env:"DLQ_SOURCE_TOPIC" envDefault:"orders"`
+}
+
+func LoadDLQConfig() *DLQConfig {
+	cfg := DLQConfig{}
+	if err := env.Parse(&cfg); err != nil {
+		log.Fatalf("Não foi possível carregar a configuração: %+v", err)
+	}
+	return &cfg
+}
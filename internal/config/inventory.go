@@ -2,16 +2,20 @@ package config
 
 import (
 	"log"
+	"time"
 
 	env "github.com/caarlos0/env/v10"
 )
 
 type InventoryConfig struct {
-	PostgresUser string `env:"POSTGRES_USER,required"`
-	PostgresPass string `env:"POSTGRES_PASS,required"`
-	PostgresHost string `env:"POSTGRES_HOST,required"`
-	PostgresDb   string `env:"POSTGRES_DB,required"`
-	KafkaBrokers string `env:"KAFKA_BROKERS,required"`
+	PostgresUser       string        `env:"POSTGRES_USER,required"`
+	PostgresPass       string        `env:"POSTGRES_PASS,required"`
+	PostgresHost       string        `env:"POSTGRES_HOST,required"`
+	PostgresDb         string        `env:"POSTGRES_DB,required"`
+	KafkaBrokers       string        `env:"KAFKA_BROKERS,required"`
+	OutboxPollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL" envDefault:"1s"`
+	ReservationTTL     time.Duration `env:"STOCK_RESERVATION_TTL" envDefault:"15m"`
+	SweepInterval      time.Duration `env:"STOCK_RESERVATION_SWEEP_INTERVAL" envDefault:"30s"`
 }
 
 func LoadInventoryConfig() *InventoryConfig {
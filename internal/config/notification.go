@@ -10,6 +10,7 @@ type NotificationConfig struct {
 	RabbitmqUser string `env:"RABBITMQ_USER,required"`
 	RabbitmqPass string `env:"RABBITMQ_PASS,required"`
 	RabbitmqHost string `env:"RABBITMQ_HOST,required"`
+	KafkaBrokers string `env:"KAFKA_BROKERS,required"`
 }
 
 func LoadNotificationConfig() *NotificationConfig {
@@ -2,23 +2,34 @@ package config
 
 import (
 	"log"
+	"time"
 
 	env "github.com/caarlos0/env/v10"
 )
 
 type OrderConfig struct {
-	PostgresUser       string `env:"POSTGRES_USER,required"`
-	PostgresPass       string `env:"POSTGRES_PASS,required"`
-	PostgresHost       string `env:"POSTGRES_HOST,required"`
-	PostgresDb         string `env:"POSTGRES_DB,required"`
-	RedisAddr          string `env:"REDIS_ADDR,required"`
-	RedisDB            int    `env:"REDIS_DB,required"`
-	KafkaBrokers       string `env:"KAFKA_BROKERS,required"`
-	ProductServiceAddr string `env:"PRODUCT_SERVICE_ADDR,required"`
-	JWTSecretKey       string `env:"JWT_SECRET_KEY,required"`
-	RabbitmqUser       string `env:"RABBITMQ_USER,required"`
-	RabbitmqPass       string `env:"RABBITMQ_PASS,required"`
-	RabbitmqHost       string `env:"RABBITMQ_HOST,required"`
+	PostgresUser       string        `env:"POSTGRES_USER,required"`
+	PostgresPass       string        `env:"POSTGRES_PASS,required"`
+	PostgresHost       string        `env:"POSTGRES_HOST,required"`
+	PostgresDb         string        `env:"POSTGRES_DB,required"`
+	LocalCacheSize     int           `env:"LOCAL_CACHE_SIZE" envDefault:"0"`
+	LocalCacheTTL      time.Duration `env:"LOCAL_CACHE_TTL" envDefault:"5s"`
+	KafkaBrokers       string        `env:"KAFKA_BROKERS,required"`
+	ProductServiceAddr string        `env:"PRODUCT_SERVICE_ADDR,required"`
+	ProductToken       string        `env:"PRODUCT_SERVICE_TOKEN"`
+	JWTSecretKey       string        `env:"JWT_SECRET_KEY"`
+	JWKSURL            string        `env:"JWT_JWKS_URL"`
+	JWKSRefresh        time.Duration `env:"JWT_JWKS_REFRESH_INTERVAL" envDefault:"10m"`
+	JWTIssuer          string        `env:"JWT_ISSUER"`
+	JWTAudience        string        `env:"JWT_AUDIENCE"`
+	RabbitmqUser       string        `env:"RABBITMQ_USER,required"`
+	RabbitmqPass       string        `env:"RABBITMQ_PASS,required"`
+	RabbitmqHost       string        `env:"RABBITMQ_HOST,required"`
+	OutboxPollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL" envDefault:"1s"`
+	IdempotencyTTL     time.Duration `env:"IDEMPOTENCY_TTL" envDefault:"24h"`
+	IdempotencyPurge   time.Duration `env:"IDEMPOTENCY_PURGE_INTERVAL" envDefault:"10m"`
+
+	Redis RedisConfig
 }
 
 func LoadOrderConfig() *OrderConfig {
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"log"
+	"time"
+
+	env "github.com/caarlos0/env/v10"
+)
+
+type ProductConfig struct {
+	PostgresUser       string        `env:"POSTGRES_USER,required"`
+	PostgresPass       string        `env:"POSTGRES_PASS,required"`
+	PostgresHost       string        `env:"POSTGRES_HOST,required"`
+	PostgresDb         string        `env:"POSTGRES_DB,required"`
+	GRPCAddr           string        `env:"GRPC_ADDR" envDefault:":50051"`
+	PriceApplyInterval time.Duration `env:"PRICE_APPLY_INTERVAL" envDefault:"1m"`
+	ServiceToken       string        `env:"PRODUCT_SERVICE_TOKEN,required"`
+}
+
+func LoadProductConfig() *ProductConfig {
+	cfg := ProductConfig{}
+	if err := env.Parse(&cfg); err != nil {
+		log.Fatalf("Não foi possível carregar a configuração: %+v", err)
+	}
+	return &cfg
+}
@@ -0,0 +1,27 @@
+package config
+
+import "time"
+
+// RedisConfig descreve a conexão com o Redis. REDIS_MODE escolhe a topologia:
+// standalone usa o primeiro endereço de REDIS_ADDR, sentinel usa os endereços
+// como sentinelas do master REDIS_MASTER_NAME e cluster os usa como nós
+// iniciais do cluster.
+type RedisConfig struct {
+	Mode             string        `env:"REDIS_MODE" envDefault:"standalone"`
+	Addrs            []string      `env:"REDIS_ADDR,required" envSeparator:","`
+	DB               int           `env:"REDIS_DB" envDefault:"0"`
+	MasterName       string        `env:"REDIS_MASTER_NAME"`
+	Username         string        `env:"REDIS_USERNAME"`
+	Password         string        `env:"REDIS_PASSWORD"`
+	SentinelUsername string        `env:"REDIS_SENTINEL_USERNAME"`
+	SentinelPassword string        `env:"REDIS_SENTINEL_PASSWORD"`
+	TLS              bool          `env:"REDIS_TLS" envDefault:"false"`
+	TLSCAFile        string        `env:"REDIS_TLS_CA_FILE"`
+	TLSServerName    string        `env:"REDIS_TLS_SERVER_NAME"`
+	PoolSize         int           `env:"REDIS_POOL_SIZE" envDefault:"0"`
+	MinIdleConns     int           `env:"REDIS_MIN_IDLE_CONNS" envDefault:"0"`
+	DialTimeout      time.Duration `env:"REDIS_DIAL_TIMEOUT" envDefault:"5s"`
+	ReadTimeout      time.Duration `env:"REDIS_READ_TIMEOUT" envDefault:"3s"`
+	WriteTimeout     time.Duration `env:"REDIS_WRITE_TIMEOUT" envDefault:"3s"`
+	PoolTimeout      time.Duration `env:"REDIS_POOL_TIMEOUT" envDefault:"4s"`
+}
@@ -5,9 +5,10 @@ import (
 	"github.com/mlucas4330/orderflow-pro/pkg/model"
 )
 
+// CreateOrderRequest não traz o cliente: o pedido é sempre criado para o
+// usuário do token.
 type CreateOrderRequest struct {
-	CustomerID uuid.UUID   `json:"customer_id" binding:"required,uuid"`
-	Items      []OrderItem `json:"items" binding:"required,min=1"`
+	Items []OrderItem `json:"items" binding:"required,min=1"`
 }
 
 type UpdateOrderRequest struct {
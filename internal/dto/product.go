@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type CreateProductRequest struct {
+	Name          string           `json:"name" binding:"required"`
+	SKU           string           `json:"sku" binding:"required"`
+	Price         *decimal.Decimal `json:"price" binding:"required"`
+	StockQuantity int              `json:"stock_quantity" binding:"gte=0"`
+}
+
+type UpdateProductRequest struct {
+	Name          *string          `json:"name"`
+	SKU           *string          `json:"sku"`
+	Price         *decimal.Decimal `json:"price"`
+	StockQuantity *int             `json:"stock_quantity" binding:"omitempty,gte=0"`
+	IsActive      *bool            `json:"is_active"`
+}
+
+type ProductResponse struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	SKU           string `json:"sku"`
+	Price         string `json:"price"`
+	StockQuantity int    `json:"stock_quantity"`
+	IsActive      bool   `json:"is_active"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+type ProductListResponse struct {
+	Products      []ProductResponse `json:"products"`
+	NextPageToken string            `json:"next_page_token,omitempty"`
+}
+
+type SchedulePriceRequest struct {
+	Price         *decimal.Decimal `json:"price" binding:"required"`
+	EffectiveFrom time.Time        `json:"effective_from" binding:"required"`
+}
+
+type PriceResponse struct {
+	ProductID     string `json:"product_id"`
+	Price         string `json:"price"`
+	EffectiveFrom string `json:"effective_from"`
+	CreatedAt     string `json:"created_at"`
+}
@@ -5,6 +5,8 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+const OrderCreatedType = "order.created"
+
 type OrderCreatedEvent struct {
 	OrderID    uuid.UUID          `json:"order_id"`
 	CustomerID uuid.UUID          `json:"customer_id"`
@@ -0,0 +1,70 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mlucas4330/orderflow-pro/pkg/model"
+)
+
+const (
+	HeaderEventType    = "event-type"
+	HeaderEventVersion = "event-version"
+)
+
+const (
+	OrdersTopic    = "orders"
+	InventoryTopic = "inventory"
+)
+
+const (
+	OrderStatusChangedType = "order.status_changed"
+	OrderCancelledType     = "order.cancelled"
+	OrderRefundedType      = "order.refunded"
+	OrderDeletedType       = "order.deleted"
+)
+
+// Versão atual do contrato dos eventos de ciclo de vida. Deve ser incrementada
+// sempre que um campo existente mudar de significado ou for removido.
+const OrderLifecycleVersion = 1
+
+type OrderStatusChangedEvent struct {
+	Version        int          `json:"version"`
+	OrderID        uuid.UUID    `json:"order_id"`
+	CustomerID     uuid.UUID    `json:"customer_id"`
+	PreviousStatus model.Status `json:"previous_status"`
+	NewStatus      model.Status `json:"new_status"`
+	Actor          uuid.UUID    `json:"actor"`
+	OccurredAt     time.Time    `json:"occurred_at"`
+}
+
+type OrderCancelledEvent struct {
+	Version        int                `json:"version"`
+	OrderID        uuid.UUID          `json:"order_id"`
+	CustomerID     uuid.UUID          `json:"customer_id"`
+	PreviousStatus model.Status       `json:"previous_status"`
+	Reason         string             `json:"reason,omitempty"`
+	Actor          uuid.UUID          `json:"actor"`
+	OccurredAt     time.Time          `json:"occurred_at"`
+	Items          []OrderItemCreated `json:"items"`
+}
+
+type OrderRefundedEvent struct {
+	Version        int                `json:"version"`
+	OrderID        uuid.UUID          `json:"order_id"`
+	CustomerID     uuid.UUID          `json:"customer_id"`
+	PreviousStatus model.Status       `json:"previous_status"`
+	Actor          uuid.UUID          `json:"actor"`
+	OccurredAt     time.Time          `json:"occurred_at"`
+	Items          []OrderItemCreated `json:"items"`
+}
+
+type OrderDeletedEvent struct {
+	Version        int                `json:"version"`
+	OrderID        uuid.UUID          `json:"order_id"`
+	CustomerID     uuid.UUID          `json:"customer_id"`
+	PreviousStatus model.Status       `json:"previous_status"`
+	Actor          uuid.UUID          `json:"actor"`
+	OccurredAt     time.Time          `json:"occurred_at"`
+	Items          []OrderItemCreated `json:"items"`
+}
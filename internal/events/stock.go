@@ -0,0 +1,50 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StockReservedType = "stock.reserved"
+	StockRejectedType = "stock.rejected"
+	StockExpiredType  = "stock.expired"
+)
+
+const StockEventVersion = 1
+
+type StockReservedEvent struct {
+	Version    int       `json:"version"`
+	OrderID    uuid.UUID `json:"order_id"`
+	CustomerID uuid.UUID `json:"customer_id"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+type StockRejectedEvent struct {
+	Version    int             `json:"version"`
+	OrderID    uuid.UUID       `json:"order_id"`
+	CustomerID uuid.UUID       `json:"customer_id"`
+	Reason     string          `json:"reason"`
+	Shortages  []StockShortage `json:"shortages,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// StockShortage descreve um item que não pôde ser reservado. Produtos que não
+// existem no catálogo aparecem com SKU vazio e Available zero.
+type StockShortage struct {
+	ProductID uuid.UUID `json:"product_id"`
+	SKU       string    `json:"sku,omitempty"`
+	Requested int       `json:"requested"`
+	Available int       `json:"available"`
+}
+
+// StockExpiredEvent avisa que a reserva do pedido venceu sem pagamento e o
+// estoque voltou a ficar disponível.
+type StockExpiredEvent struct {
+	Version    int       `json:"version"`
+	OrderID    uuid.UUID `json:"order_id"`
+	ExpiredAt  time.Time `json:"expired_at"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
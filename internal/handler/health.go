@@ -6,14 +6,16 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mlucas4330/orderflow-pro/internal/cache"
 )
 
 type HealthHandler struct {
-	DB *pgxpool.Pool
+	DB    *pgxpool.Pool
+	Cache *cache.Breaker
 }
 
-func NewHealthHandler(db *pgxpool.Pool) *HealthHandler {
-	return &HealthHandler{DB: db}
+func NewHealthHandler(db *pgxpool.Pool, cache *cache.Breaker) *HealthHandler {
+	return &HealthHandler{DB: db, Cache: cache}
 }
 
 func (h *HealthHandler) Check(c *gin.Context) {
@@ -30,7 +32,20 @@ func (h *HealthHandler) Check(c *gin.Context) {
 		return
 	}
 
+	// Sem o Redis o serviço continua atendendo a partir do Postgres, então o
+	// cache só é informado e não derruba o health check.
 	c.JSON(http.StatusOK, gin.H{
 		"message": "pong",
+		"cache":   h.cacheStatus(),
 	})
 }
+
+func (h *HealthHandler) cacheStatus() string {
+	if h.Cache == nil {
+		return "disabled"
+	}
+	if !h.Cache.Available() {
+		return "degraded"
+	}
+	return "up"
+}
@@ -1,54 +1,145 @@
 package handler
 
 import (
-	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	pgx "github.com/jackc/pgx/v5"
 	"github.com/mlucas4330/orderflow-pro/internal/dto"
+	"github.com/mlucas4330/orderflow-pro/internal/middleware"
 	"github.com/mlucas4330/orderflow-pro/internal/repository"
 	"github.com/mlucas4330/orderflow-pro/pkg/model"
 	pb "github.com/mlucas4330/orderflow-pro/pkg/productpb"
 
 	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type OrderHandler struct {
-	OrderRepo       repository.OrderRepository
-	IdempotencyRepo repository.IdempotencyRepository
-	ProductClient   pb.ProductServiceClient
+	OrderRepo     repository.OrderRepository
+	ProductClient pb.ProductServiceClient
 }
 
-func NewOrderHandler(orderRepo repository.OrderRepository, idempotencyRepo repository.IdempotencyRepository, productClient pb.ProductServiceClient) *OrderHandler {
-	return &OrderHandler{OrderRepo: orderRepo, IdempotencyRepo: idempotencyRepo, ProductClient: productClient}
+func NewOrderHandler(orderRepo repository.OrderRepository, productClient pb.ProductServiceClient) *OrderHandler {
+	return &OrderHandler{OrderRepo: orderRepo, ProductClient: productClient}
 }
 
 func (h *OrderHandler) GetOrders(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	orders, err := h.OrderRepo.FindOrders(ctx)
+	filter, err := parseOrderFilter(c)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "pedidos não encontrados"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Sem orders:read:all só se listam os próprios pedidos; o customer_id da
+	// query, se vier, precisa ser o do token.
+	if !middleware.HasScope(c, middleware.ScopeOrdersReadAll) {
+		userID, ok := middleware.UserIDFromContext(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "usuário não autenticado"})
+			return
+		}
+		if filter.CustomerID != nil && *filter.CustomerID != userID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "não é permitido listar pedidos de outro cliente"})
+			return
+		}
+		filter.CustomerID = &userID
+	}
+
+	page, err := h.OrderRepo.FindOrders(ctx, filter)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
 		log.Printf("Erro ao buscar pedidos no repositório: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "erro interno ao buscar o pedido"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "erro interno ao buscar os pedidos"})
 		return
 	}
 
-	c.JSON(http.StatusOK, orders)
+	c.JSON(http.StatusOK, page)
 }
 
-func (h *OrderHandler) GetOrderById(c *gin.Context) {
-	ctx := c.Request.Context()
+// parseOrderFilter lê os parâmetros de listagem. O sort aceita um prefixo "-"
+// para ordem decrescente (ex.: sort=-total); o padrão é -created_at.
+func parseOrderFilter(c *gin.Context) (repository.OrderFilter, error) {
+	filter := repository.OrderFilter{
+		SortBy:     repository.SortByCreatedAt,
+		Descending: true,
+		Limit:      repository.DefaultOrdersPageSize,
+		Cursor:     c.Query("cursor"),
+	}
+
+	if raw := c.Query("status"); raw != "" {
+		for _, value := range strings.Split(raw, ",") {
+			status := model.Status(strings.TrimSpace(value))
+			if !status.IsValid() {
+				return filter, fmt.Errorf("status inválido: %s", value)
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	if raw := c.Query("customer_id"); raw != "" {
+		customerID, err := uuid.Parse(raw)
+		if err != nil {
+			return filter, errors.New("customer_id inválido")
+		}
+		filter.CustomerID = &customerID
+	}
+
+	for param, target := range map[string]**time.Time{"created_from": &filter.CreatedFrom, "created_to": &filter.CreatedTo} {
+		if raw := c.Query(param); raw != "" {
+			value, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return filter, fmt.Errorf("%s deve estar no formato RFC3339", param)
+			}
+			*target = &value
+		}
+	}
+
+	for param, target := range map[string]**decimal.Decimal{"min_total": &filter.MinTotal, "max_total": &filter.MaxTotal} {
+		if raw := c.Query(param); raw != "" {
+			value, err := decimal.NewFromString(raw)
+			if err != nil {
+				return filter, fmt.Errorf("%s inválido", param)
+			}
+			*target = &value
+		}
+	}
 
+	if raw := c.Query("sort"); raw != "" {
+		field, descending := strings.CutPrefix(raw, "-")
+		sortBy := repository.OrderSortField(field)
+		if !sortBy.IsValid() {
+			return filter, fmt.Errorf("ordenação não permitida: %s", field)
+		}
+		filter.SortBy, filter.Descending = sortBy, descending
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > repository.MaxOrdersPageSize {
+			return filter, fmt.Errorf("limit deve estar entre 1 e %d", repository.MaxOrdersPageSize)
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+func (h *OrderHandler) GetOrderById(c *gin.Context) {
 	idStr := c.Param("id")
 
 	id, err := uuid.Parse(idStr)
@@ -57,35 +148,52 @@ func (h *OrderHandler) GetOrderById(c *gin.Context) {
 		return
 	}
 
-	order, err := h.OrderRepo.FindOrderById(ctx, id)
+	order, ok := h.authorizeOrder(c, id, middleware.ScopeOrdersReadAll)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// authorizeOrder carrega o pedido e confere se ele pertence ao usuário do token.
+// Pedidos de outros clientes respondem 404, como os inexistentes, para não
+// revelar que existem. Quem tem algum dos escopos anyScopes acessa qualquer
+// pedido.
+func (h *OrderHandler) authorizeOrder(c *gin.Context, id uuid.UUID, anyScopes ...string) (*model.Order, bool) {
+	userID, ok := middleware.UserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "usuário não autenticado"})
+		return nil, false
+	}
+
+	order, err := h.OrderRepo.FindOrderById(c.Request.Context(), id)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "pedido não encontrado"})
-			return
+			return nil, false
 		}
 
 		log.Printf("Erro ao buscar pedido por ID no repositório: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "erro interno ao buscar o pedido"})
-		return
+		return nil, false
 	}
 
-	c.JSON(http.StatusOK, order)
+	if order.CustomerID != userID && !middleware.HasScope(c, anyScopes...) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pedido não encontrado"})
+		return nil, false
+	}
+
+	return order, true
 }
 
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	idempotencyKeyStr := c.GetHeader("Idempotency-Key")
-	idempotencyKey, err := uuid.Parse(idempotencyKeyStr)
-
-	if err == nil {
-		mockCustomerID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-
-		if savedResponse, err := h.IdempotencyRepo.GetResponse(ctx, idempotencyKey, mockCustomerID); err == nil && savedResponse != nil {
-			log.Printf("HIT de idempotência para a chave: %s", idempotencyKeyStr)
-			c.Data(savedResponse.StatusCode, "application/json; charset=utf-8", savedResponse.Body)
-			return
-		}
+	customerID, ok := middleware.UserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "usuário não autenticado"})
+		return
 	}
 
 	var req dto.CreateOrderRequest
@@ -98,20 +206,13 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	var orderItems []model.OrderItem
 	total := decimal.NewFromInt(0)
 
+	prices, ok := h.priceItems(c, req.Items)
+	if !ok {
+		return
+	}
+
 	for _, itemDTO := range req.Items {
-		log.Printf("Buscando detalhes do produto %s via gRPC...", itemDTO.ProductID)
-		productDetails, err := h.ProductClient.GetProductDetails(ctx, &pb.GetProductDetailsRequest{
-			ProductId: itemDTO.ProductID.String(),
-		})
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "produto inválido: " + itemDTO.ProductID.String()})
-			return
-		}
-		priceAtTime, err := decimal.NewFromString(productDetails.GetPrice())
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "preço inválido retornado pelo serviço de produto"})
-			return
-		}
+		priceAtTime := prices[itemDTO.ProductID]
 
 		orderItem := model.OrderItem{
 			ID:          uuid.New(),
@@ -128,7 +229,7 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 
 	order := &model.Order{
 		ID:         orderID,
-		CustomerID: req.CustomerID,
+		CustomerID: customerID,
 		Status:     model.StatusPending,
 		Total:      total,
 		Currency:   "BRL",
@@ -143,19 +244,70 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
-	if idempotencyKey != uuid.Nil {
-		responseBody, _ := json.Marshal(order)
-		responseToSave := &model.IdempotencyResponse{
-			StatusCode: http.StatusCreated,
-			Body:       responseBody,
+	c.JSON(http.StatusCreated, order)
+}
+
+// priceItems busca o preço de todos os produtos do pedido numa única chamada
+// ao product-service. Se algum produto não existir ou estiver inativo, responde
+// 400 listando todos eles e devolve ok=false.
+func (h *OrderHandler) priceItems(c *gin.Context, items []dto.OrderItem) (map[uuid.UUID]decimal.Decimal, bool) {
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductID.String())
+	}
+
+	res, err := h.ProductClient.BatchGetProductDetails(c.Request.Context(), &pb.BatchGetProductDetailsRequest{ProductIds: ids})
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			c.JSON(http.StatusBadRequest, gin.H{"error": status.Convert(err).Message()})
+			return nil, false
 		}
+		log.Printf("Erro ao buscar produtos no product-service: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "serviço de produtos indisponível"})
+		return nil, false
+	}
+
+	if len(res.GetMissingIds()) > 0 || len(res.GetInactiveIds()) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "o pedido contém produtos inválidos",
+			"missing_products":  nonNil(res.GetMissingIds()),
+			"inactive_products": nonNil(res.GetInactiveIds()),
+		})
+		return nil, false
+	}
 
-		if err := h.IdempotencyRepo.SaveResponse(ctx, idempotencyKey, req.CustomerID, responseToSave); err != nil {
-			log.Printf("AVISO CRÍTICO: Falha ao salvar a resposta de idempotência: %v", err)
+	prices := make(map[uuid.UUID]decimal.Decimal, len(res.GetProducts()))
+	for _, product := range res.GetProducts() {
+		id, err := uuid.Parse(product.GetId())
+		if err != nil {
+			log.Printf("ID de produto inválido retornado pelo product-service: %q", product.GetId())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "resposta inválida do serviço de produto"})
+			return nil, false
+		}
+		price, err := decimal.NewFromString(product.GetPrice())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "preço inválido retornado pelo serviço de produto"})
+			return nil, false
 		}
+		prices[id] = price
 	}
 
-	c.JSON(http.StatusCreated, order)
+	for _, item := range items {
+		if _, ok := prices[item.ProductID]; !ok {
+			log.Printf("Produto %s ausente da resposta do product-service", item.ProductID)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "resposta inválida do serviço de produto"})
+			return nil, false
+		}
+	}
+
+	return prices, true
+}
+
+func nonNil(ids []string) []string {
+	if ids == nil {
+		return []string{}
+	}
+	return ids
 }
 
 func (h *OrderHandler) UpdateOrder(c *gin.Context) {
@@ -172,14 +324,66 @@ func (h *OrderHandler) UpdateOrder(c *gin.Context) {
 		return
 	}
 
+	if !req.Status.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status inválido: " + string(req.Status), "valid_statuses": model.Statuses()})
+		return
+	}
+
+	// A confirmação só acontece pela saga, quando o inventário reserva o estoque.
+	if req.Status == model.StatusConfirmed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "o status confirmed é definido pelo sistema após a reserva de estoque"})
+		return
+	}
+
+	// Envio e entrega são feitos pela expedição e pagamento e reembolso pelo
+	// financeiro, em pedidos de qualquer cliente. Com orders:update o cliente só
+	// cancela o próprio pedido; o resto exige orders:update:all.
+	var required []string
+	anyOrderScope := middleware.ScopeOrdersUpdateAll
+	switch req.Status {
+	case model.StatusShipped, model.StatusDelivered:
+		required = []string{middleware.ScopeOrdersShip}
+		anyOrderScope = middleware.ScopeOrdersShip
+	case model.StatusPaid, model.StatusRefunded:
+		required = []string{middleware.ScopeOrdersPay}
+		anyOrderScope = middleware.ScopeOrdersPay
+	case model.StatusCancelled:
+		required = []string{middleware.ScopeOrdersUpdate, middleware.ScopeOrdersUpdateAll}
+	default:
+		required = []string{middleware.ScopeOrdersUpdateAll}
+	}
+	if !middleware.HasScope(c, required...) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "permissão insuficiente para marcar o pedido como " + string(req.Status)})
+		return
+	}
+
+	if _, ok := h.authorizeOrder(c, id, anyOrderScope); !ok {
+		return
+	}
+
+	actor, _ := middleware.UserIDFromContext(c)
+
 	ctx := c.Request.Context()
-	err = h.OrderRepo.UpdateOrder(ctx, id, model.Status(req.Status))
+	_, err = h.OrderRepo.UpdateOrder(ctx, id, req.Status, actor)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "pedido não encontrado para atualização"})
 			return
 		}
+		var transitionErr *model.TransitionError
+		if errors.As(err, &transitionErr) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":               transitionErr.Error(),
+				"current_status":      transitionErr.From,
+				"allowed_transitions": transitionErr.Allowed(),
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrOrderStatusConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		log.Printf("Erro ao atualizar pedido no repositório: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "erro interno ao processar o pedido"})
 		return
@@ -196,8 +400,14 @@ func (h *OrderHandler) DeleteOrder(c *gin.Context) {
 		return
 	}
 
+	if _, ok := h.authorizeOrder(c, id, middleware.ScopeOrdersDelete); !ok {
+		return
+	}
+
+	actor, _ := middleware.UserIDFromContext(c)
+
 	ctx := c.Request.Context()
-	err = h.OrderRepo.DeleteOrder(ctx, id)
+	err = h.OrderRepo.DeleteOrder(ctx, id, actor)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -11,11 +11,13 @@ import (
 	"github.com/gin-gonic/gin"
 	jwt "github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	pgx "github.com/jackc/pgx/v5"
 	"github.com/mlucas4330/orderflow-pro/internal/config"
 	"github.com/mlucas4330/orderflow-pro/internal/dto"
 	"github.com/mlucas4330/orderflow-pro/internal/handler"
 	"github.com/mlucas4330/orderflow-pro/internal/middleware"
 	"github.com/mlucas4330/orderflow-pro/internal/repository"
+	"github.com/mlucas4330/orderflow-pro/pkg/model"
 	pb "github.com/mlucas4330/orderflow-pro/pkg/productpb"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -42,22 +44,28 @@ func TestCreateOrderHandler(t *testing.T) {
 	mockProductClient := new(repository.MockProductServiceClient)
 
 	mockIdemRepo.On(
-		"GetResponse",
+		"ClaimKey",
 		mock.Anything,
 		mock.AnythingOfType("uuid.UUID"),
 		mock.AnythingOfType("uuid.UUID"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("time.Duration"),
 	).Return(nil, nil)
 
+	productID := uuid.New()
+	userID := uuid.New()
 	mockProductClient.On(
-		"GetProductDetails",
+		"BatchGetProductDetails",
 		mock.Anything,
-		mock.AnythingOfType("*productpb.GetProductDetailsRequest"),
-	).Return(&pb.GetProductDetailsResponse{Price: "19.99"}, nil)
+		&pb.BatchGetProductDetailsRequest{ProductIds: []string{productID.String()}},
+	).Return(&pb.BatchGetProductDetailsResponse{
+		Products: []*pb.GetProductDetailsResponse{{Id: productID.String(), Price: "19.99"}},
+	}, nil)
 
 	mockOrderRepo.On(
 		"CreateOrder",
 		mock.Anything,
-		mock.AnythingOfType("*model.Order"),
+		mock.MatchedBy(func(order *model.Order) bool { return order.CustomerID == userID }),
 		mock.AnythingOfType("[]model.OrderItem"),
 	).Return(nil)
 
@@ -65,20 +73,19 @@ func TestCreateOrderHandler(t *testing.T) {
 		"SaveResponse",
 		mock.Anything,
 		mock.AnythingOfType("uuid.UUID"),
-		mock.AnythingOfType("uuid.UUID"),
-		mock.AnythingOfType("*model.IdempotencyResponse"),
+		userID,
+		mock.MatchedBy(func(res *model.IdempotencyResponse) bool { return res.StatusCode == http.StatusCreated }),
+		time.Hour,
 	).Return(nil)
 
-	orderHandler := handler.NewOrderHandler(mockOrderRepo, mockIdemRepo, mockProductClient)
+	orderHandler := handler.NewOrderHandler(mockOrderRepo, mockProductClient)
 	authMiddleware := middleware.NewAuthMiddleware(cfg.JWTSecretKey)
 
 	router := gin.New()
-	router.POST("/api/v1/orders", authMiddleware, orderHandler.CreateOrder)
+	router.POST("/api/v1/orders", authMiddleware, middleware.NewIdempotencyMiddleware(mockIdemRepo, time.Hour), orderHandler.CreateOrder)
 
-	userID := uuid.New()
 	createDTO := dto.CreateOrderRequest{
-		CustomerID: userID,
-		Items:      []dto.OrderItem{{ProductID: uuid.New(), Quantity: 1}},
+		Items: []dto.OrderItem{{ProductID: productID, Quantity: 1}},
 	}
 	body, _ := json.Marshal(createDTO)
 	token := generateTestToken(t, userID, cfg.JWTSecretKey)
@@ -97,3 +104,288 @@ func TestCreateOrderHandler(t *testing.T) {
 	mockIdemRepo.AssertExpectations(t)
 	mockProductClient.AssertExpectations(t)
 }
+
+func TestUpdateOrderHandlerInvalidTransition(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.LoadOrderConfig()
+
+	mockOrderRepo := new(repository.MockOrderRepository)
+	orderID := uuid.New()
+	userID := uuid.New()
+
+	mockOrderRepo.On("FindOrderById", mock.Anything, orderID).Return(&model.Order{ID: orderID, CustomerID: userID}, nil)
+	mockOrderRepo.On("UpdateOrder", mock.Anything, orderID, model.StatusPending, userID).
+		Return(model.StatusDelivered, &model.TransitionError{From: model.StatusDelivered, To: model.StatusPending})
+
+	orderHandler := handler.NewOrderHandler(mockOrderRepo, new(repository.MockProductServiceClient))
+	authMiddleware := middleware.NewAuthMiddleware(cfg.JWTSecretKey)
+
+	router := gin.New()
+	router.PATCH("/api/v1/orders/:id", authMiddleware, orderHandler.UpdateOrder)
+
+	body, _ := json.Marshal(dto.UpdateOrderRequest{Status: model.StatusPending})
+	req, _ := http.NewRequest(http.MethodPatch, "/api/v1/orders/"+orderID.String(), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+generateTokenWithRoles(t, userID, cfg.JWTSecretKey, middleware.RoleAdmin))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusConflict, w.Code)
+
+	var resp struct {
+		CurrentStatus      model.Status   `json:"current_status"`
+		AllowedTransitions []model.Status `json:"allowed_transitions"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, model.StatusDelivered, resp.CurrentStatus)
+	require.Equal(t, []model.Status{model.StatusRefunded}, resp.AllowedTransitions)
+
+	mockOrderRepo.AssertExpectations(t)
+}
+
+func TestUpdateOrderHandlerUnknownStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.LoadOrderConfig()
+
+	mockOrderRepo := new(repository.MockOrderRepository)
+
+	orderHandler := handler.NewOrderHandler(mockOrderRepo, new(repository.MockProductServiceClient))
+	authMiddleware := middleware.NewAuthMiddleware(cfg.JWTSecretKey)
+
+	router := gin.New()
+	router.PATCH("/api/v1/orders/:id", authMiddleware, orderHandler.UpdateOrder)
+
+	req, _ := http.NewRequest(http.MethodPatch, "/api/v1/orders/"+uuid.New().String(), bytes.NewReader([]byte(`{"status":"archived"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+generateTestToken(t, uuid.New(), cfg.JWTSecretKey))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	mockOrderRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateOrderHandlerInvalidProducts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.LoadOrderConfig()
+
+	mockOrderRepo := new(repository.MockOrderRepository)
+	mockProductClient := new(repository.MockProductServiceClient)
+
+	valid, missing, inactive := uuid.New(), uuid.New(), uuid.New()
+	mockProductClient.On("BatchGetProductDetails", mock.Anything, mock.Anything).Return(&pb.BatchGetProductDetailsResponse{
+		Products:    []*pb.GetProductDetailsResponse{{Id: valid.String(), Price: "10.00"}},
+		MissingIds:  []string{missing.String()},
+		InactiveIds: []string{inactive.String()},
+	}, nil)
+
+	orderHandler := handler.NewOrderHandler(mockOrderRepo, mockProductClient)
+	authMiddleware := middleware.NewAuthMiddleware(cfg.JWTSecretKey)
+
+	router := gin.New()
+	router.POST("/api/v1/orders", authMiddleware, orderHandler.CreateOrder)
+
+	userID := uuid.New()
+	body, _ := json.Marshal(dto.CreateOrderRequest{
+		Items: []dto.OrderItem{
+			{ProductID: valid, Quantity: 1},
+			{ProductID: missing, Quantity: 1},
+			{ProductID: inactive, Quantity: 2},
+		},
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/orders", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+generateTestToken(t, userID, cfg.JWTSecretKey))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var res struct {
+		Missing  []string `json:"missing_products"`
+		Inactive []string `json:"inactive_products"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Equal(t, []string{missing.String()}, res.Missing)
+	require.Equal(t, []string{inactive.String()}, res.Inactive)
+
+	mockOrderRepo.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetOrdersHandlerParsesFilters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.LoadOrderConfig()
+
+	mockOrderRepo := new(repository.MockOrderRepository)
+	customerID := uuid.New()
+
+	mockOrderRepo.On("FindOrders", mock.Anything, mock.MatchedBy(func(filter repository.OrderFilter) bool {
+		return filter.SortBy == repository.SortByTotal &&
+			!filter.Descending &&
+			filter.Limit == 5 &&
+			filter.Cursor == "abc" &&
+			*filter.CustomerID == customerID &&
+			filter.MinTotal.String() == "10.5" &&
+			filter.CreatedFrom.Equal(time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)) &&
+			len(filter.Statuses) == 2 && filter.Statuses[1] == model.StatusPaid
+	})).Return(&repository.OrderPage{Orders: []model.Order{}, NextCursor: "next"}, nil)
+
+	orderHandler := handler.NewOrderHandler(mockOrderRepo, new(repository.MockProductServiceClient))
+	authMiddleware := middleware.NewAuthMiddleware(cfg.JWTSecretKey)
+
+	router := gin.New()
+	router.GET("/api/v1/orders", authMiddleware, orderHandler.GetOrders)
+
+	query := "?status=pending,paid&customer_id=" + customerID.String() +
+		"&created_from=2025-08-01T00:00:00Z&min_total=10.5&sort=total&limit=5&cursor=abc"
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/orders"+query, nil)
+	req.Header.Set("Authorization", "Bearer "+generateTestToken(t, customerID, cfg.JWTSecretKey))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var page repository.OrderPage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	require.Equal(t, "next", page.NextCursor)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+func TestGetOrdersHandlerRejectsInvalidQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.LoadOrderConfig()
+
+	mockOrderRepo := new(repository.MockOrderRepository)
+
+	orderHandler := handler.NewOrderHandler(mockOrderRepo, new(repository.MockProductServiceClient))
+	authMiddleware := middleware.NewAuthMiddleware(cfg.JWTSecretKey)
+
+	router := gin.New()
+	router.GET("/api/v1/orders", authMiddleware, orderHandler.GetOrders)
+
+	for _, query := range []string{"?sort=customer_id", "?status=archived", "?limit=500", "?created_to=ontem"} {
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/orders"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestToken(t, uuid.New(), cfg.JWTSecretKey))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+
+	mockOrderRepo.AssertNotCalled(t, "FindOrders", mock.Anything, mock.Anything)
+}
+
+func TestOrderHandlersScopeToCustomer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.LoadOrderConfig()
+
+	mockOrderRepo := new(repository.MockOrderRepository)
+	owner, stranger := uuid.New(), uuid.New()
+	orderID := uuid.New()
+
+	mockOrderRepo.On("FindOrderById", mock.Anything, orderID).Return(&model.Order{ID: orderID, CustomerID: owner}, nil)
+	mockOrderRepo.On("FindOrderById", mock.Anything, mock.Anything).Return(nil, pgx.ErrNoRows)
+	mockOrderRepo.On("FindOrders", mock.Anything, mock.MatchedBy(func(filter repository.OrderFilter) bool {
+		return filter.CustomerID != nil && *filter.CustomerID == stranger
+	})).Return(&repository.OrderPage{Orders: []model.Order{}}, nil)
+
+	orderHandler := handler.NewOrderHandler(mockOrderRepo, new(repository.MockProductServiceClient))
+	authMiddleware := middleware.NewAuthMiddleware(cfg.JWTSecretKey)
+
+	router := gin.New()
+	router.GET("/api/v1/orders", authMiddleware, orderHandler.GetOrders)
+	router.GET("/api/v1/orders/:id", authMiddleware, orderHandler.GetOrderById)
+	router.PATCH("/api/v1/orders/:id", authMiddleware, orderHandler.UpdateOrder)
+	router.DELETE("/api/v1/orders/:id", authMiddleware, orderHandler.DeleteOrder)
+
+	send := func(method, path, body, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	token := generateTestToken(t, stranger, cfg.JWTSecretKey)
+	foreign := send(http.MethodGet, "/api/v1/orders/"+orderID.String(), "", token)
+	missing := send(http.MethodGet, "/api/v1/orders/"+uuid.New().String(), "", token)
+	require.Equal(t, http.StatusNotFound, foreign.Code)
+	require.Equal(t, missing.Body.String(), foreign.Body.String(), "Pedido de outro cliente deveria responder igual a um pedido inexistente")
+
+	require.Equal(t, http.StatusNotFound, send(http.MethodPatch, "/api/v1/orders/"+orderID.String(), `{"status":"cancelled"}`, token).Code)
+	require.Equal(t, http.StatusNotFound, send(http.MethodDelete, "/api/v1/orders/"+orderID.String(), "", token).Code)
+	mockOrderRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockOrderRepo.AssertNotCalled(t, "DeleteOrder", mock.Anything, mock.Anything, mock.Anything)
+
+	require.Equal(t, http.StatusForbidden, send(http.MethodGet, "/api/v1/orders?customer_id="+owner.String(), "", token).Code)
+	require.Equal(t, http.StatusOK, send(http.MethodGet, "/api/v1/orders", "", token).Code)
+
+	adminToken := generateTokenWithRoles(t, uuid.New(), cfg.JWTSecretKey, middleware.RoleAdmin)
+	require.Equal(t, http.StatusOK, send(http.MethodGet, "/api/v1/orders/"+orderID.String(), "", adminToken).Code)
+}
+
+func TestOrderRoutesRequireScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.LoadOrderConfig()
+
+	mockOrderRepo := new(repository.MockOrderRepository)
+	customer, warehouse := uuid.New(), uuid.New()
+	orderID := uuid.New()
+
+	mockOrderRepo.On("FindOrderById", mock.Anything, orderID).Return(&model.Order{ID: orderID, CustomerID: customer}, nil)
+	mockOrderRepo.On("UpdateOrder", mock.Anything, orderID, model.StatusShipped, warehouse).Return(model.StatusPaid, nil)
+	mockOrderRepo.On("UpdateOrder", mock.Anything, orderID, model.StatusCancelled, customer).Return(model.StatusPending, nil)
+	mockOrderRepo.On("DeleteOrder", mock.Anything, orderID, mock.Anything).Return(nil)
+
+	orderHandler := handler.NewOrderHandler(mockOrderRepo, new(repository.MockProductServiceClient))
+	authMiddleware := middleware.NewAuthMiddleware(cfg.JWTSecretKey)
+
+	router := gin.New()
+	router.GET("/api/v1/orders/:id", authMiddleware, middleware.RequireScope(middleware.ScopeOrdersRead), orderHandler.GetOrderById)
+	router.PATCH("/api/v1/orders/:id", authMiddleware, middleware.RequireScope(middleware.ScopeOrdersUpdate, middleware.ScopeOrdersUpdateAll, middleware.ScopeOrdersShip, middleware.ScopeOrdersPay), orderHandler.UpdateOrder)
+	router.DELETE("/api/v1/orders/:id", authMiddleware, middleware.RequireScope(middleware.ScopeOrdersDelete), orderHandler.DeleteOrder)
+
+	send := func(method, body, token string) int {
+		req, _ := http.NewRequest(method, "/api/v1/orders/"+orderID.String(), bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	customerToken := generateTokenWithRoles(t, customer, cfg.JWTSecretKey, middleware.RoleCustomer)
+	supportToken := generateTokenWithRoles(t, uuid.New(), cfg.JWTSecretKey, middleware.RoleSupport)
+	warehouseToken := generateTokenWithRoles(t, warehouse, cfg.JWTSecretKey, middleware.RoleWarehouse)
+	adminToken := generateTokenWithRoles(t, uuid.New(), cfg.JWTSecretKey, middleware.RoleAdmin)
+
+	require.Equal(t, http.StatusOK, send(http.MethodGet, "", supportToken), "Suporte deveria ler pedidos de qualquer cliente")
+	require.Equal(t, http.StatusForbidden, send(http.MethodPatch, `{"status":"cancelled"}`, supportToken))
+
+	require.Equal(t, http.StatusForbidden, send(http.MethodPatch, `{"status":"shipped"}`, customerToken), "Cliente não deveria despachar o próprio pedido")
+	require.Equal(t, http.StatusForbidden, send(http.MethodPatch, `{"status":"paid"}`, customerToken), "Cliente não deveria marcar o próprio pedido como pago")
+	require.Equal(t, http.StatusForbidden, send(http.MethodPatch, `{"status":"refunded"}`, customerToken), "Cliente não deveria reembolsar o próprio pedido")
+	require.Equal(t, http.StatusForbidden, send(http.MethodPatch, `{"status":"paid"}`, generateTestToken(t, customer, cfg.JWTSecretKey)), "Token sem papel é de cliente e não deveria marcar o pedido como pago")
+	require.Equal(t, http.StatusNoContent, send(http.MethodPatch, `{"status":"cancelled"}`, customerToken), "Cliente deveria cancelar o próprio pedido")
+	require.Equal(t, http.StatusForbidden, send(http.MethodPatch, `{"status":"cancelled"}`, warehouseToken))
+	require.Equal(t, http.StatusNoContent, send(http.MethodPatch, `{"status":"shipped"}`, warehouseToken))
+
+	require.Equal(t, http.StatusForbidden, send(http.MethodDelete, "", customerToken), "Apenas administradores excluem pedidos")
+	require.Equal(t, http.StatusNoContent, send(http.MethodDelete, "", adminToken))
+
+	mockOrderRepo.AssertExpectations(t)
+}
@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mlucas4330/orderflow-pro/internal/dto"
+	pb "github.com/mlucas4330/orderflow-pro/pkg/productpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const errNonPositivePrice = "o preço deve ser maior que zero"
+
+// ProductHandler expõe o catálogo do product-service via REST.
+type ProductHandler struct {
+	ProductClient pb.ProductServiceClient
+}
+
+func NewProductHandler(productClient pb.ProductServiceClient) *ProductHandler {
+	return &ProductHandler{ProductClient: productClient}
+}
+
+func (h *ProductHandler) ListProducts(c *gin.Context) {
+	req := &pb.ListProductsRequest{
+		PageToken: c.Query("page_token"),
+		Name:      c.Query("name"),
+		Sku:       c.Query("sku"),
+	}
+
+	if raw := c.Query("page_size"); raw != "" {
+		pageSize, err := strconv.Atoi(raw)
+		if err != nil || pageSize <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "page_size inválido"})
+			return
+		}
+		req.PageSize = int32(pageSize)
+	}
+
+	if raw := c.Query("active"); raw != "" {
+		active, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "active deve ser true ou false"})
+			return
+		}
+		req.Active = proto.Bool(active)
+	}
+
+	res, err := h.ProductClient.ListProducts(c.Request.Context(), req)
+	if err != nil {
+		respondProductError(c, err)
+		return
+	}
+
+	products := make([]dto.ProductResponse, 0, len(res.GetProducts()))
+	for _, product := range res.GetProducts() {
+		products = append(products, toProductResponse(product))
+	}
+
+	c.JSON(http.StatusOK, dto.ProductListResponse{Products: products, NextPageToken: res.GetNextPageToken()})
+}
+
+func (h *ProductHandler) GetProductBySku(c *gin.Context) {
+	res, err := h.ProductClient.GetProductBySku(c.Request.Context(), &pb.GetProductBySkuRequest{Sku: c.Param("sku")})
+	if err != nil {
+		respondProductError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toProductResponse(res))
+}
+
+func (h *ProductHandler) CreateProduct(c *gin.Context) {
+	var req dto.CreateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "corpo da requisição inválido: " + err.Error()})
+		return
+	}
+
+	if !req.Price.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errNonPositivePrice})
+		return
+	}
+
+	res, err := h.ProductClient.CreateProduct(c.Request.Context(), &pb.CreateProductRequest{
+		Name:          req.Name,
+		Sku:           req.SKU,
+		Price:         req.Price.String(),
+		StockQuantity: int32(req.StockQuantity),
+	})
+	if err != nil {
+		respondProductError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toProductResponse(res))
+}
+
+func (h *ProductHandler) UpdateProduct(c *gin.Context) {
+	var req dto.UpdateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "corpo da requisição inválido: " + err.Error()})
+		return
+	}
+
+	update := &pb.UpdateProductRequest{
+		Id:       c.Param("id"),
+		Name:     req.Name,
+		Sku:      req.SKU,
+		IsActive: req.IsActive,
+	}
+	if req.Price != nil {
+		if !req.Price.IsPositive() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errNonPositivePrice})
+			return
+		}
+		update.Price = proto.String(req.Price.String())
+	}
+	if req.StockQuantity != nil {
+		update.StockQuantity = proto.Int32(int32(*req.StockQuantity))
+	}
+
+	res, err := h.ProductClient.UpdateProduct(c.Request.Context(), update)
+	if err != nil {
+		respondProductError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toProductResponse(res))
+}
+
+func (h *ProductHandler) DeactivateProduct(c *gin.Context) {
+	res, err := h.ProductClient.DeactivateProduct(c.Request.Context(), &pb.DeactivateProductRequest{Id: c.Param("id")})
+	if err != nil {
+		respondProductError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toProductResponse(res))
+}
+
+func (h *ProductHandler) GetPriceAt(c *gin.Context) {
+	res, err := h.ProductClient.GetPriceAt(c.Request.Context(), &pb.GetPriceAtRequest{ProductId: c.Param("id"), At: c.Query("at")})
+	if err != nil {
+		respondProductError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toPriceResponse(res))
+}
+
+func (h *ProductHandler) ListPriceHistory(c *gin.Context) {
+	res, err := h.ProductClient.ListPriceHistory(c.Request.Context(), &pb.ListPriceHistoryRequest{ProductId: c.Param("id")})
+	if err != nil {
+		respondProductError(c, err)
+		return
+	}
+
+	prices := make([]dto.PriceResponse, 0, len(res.GetPrices()))
+	for _, price := range res.GetPrices() {
+		prices = append(prices, toPriceResponse(price))
+	}
+
+	c.JSON(http.StatusOK, prices)
+}
+
+func (h *ProductHandler) SchedulePriceChange(c *gin.Context) {
+	var req dto.SchedulePriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "corpo da requisição inválido: " + err.Error()})
+		return
+	}
+
+	if !req.Price.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errNonPositivePrice})
+		return
+	}
+
+	res, err := h.ProductClient.SchedulePriceChange(c.Request.Context(), &pb.SchedulePriceChangeRequest{
+		ProductId:     c.Param("id"),
+		Price:         req.Price.String(),
+		EffectiveFrom: req.EffectiveFrom.Format(time.RFC3339),
+	})
+	if err != nil {
+		respondProductError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toPriceResponse(res))
+}
+
+// respondProductError traduz o status gRPC do product-service para HTTP.
+func respondProductError(c *gin.Context, err error) {
+	st := status.Convert(err)
+
+	switch st.Code() {
+	case codes.InvalidArgument:
+		c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+	case codes.NotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": st.Message()})
+	case codes.AlreadyExists:
+		c.JSON(http.StatusConflict, gin.H{"error": st.Message()})
+	case codes.FailedPrecondition:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": st.Message()})
+	default:
+		log.Printf("Erro ao chamar o product-service: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "serviço de produtos indisponível"})
+	}
+}
+
+func toProductResponse(product *pb.Product) dto.ProductResponse {
+	return dto.ProductResponse{
+		ID:            product.GetId(),
+		Name:          product.GetName(),
+		SKU:           product.GetSku(),
+		Price:         product.GetPrice(),
+		StockQuantity: int(product.GetStockQuantity()),
+		IsActive:      product.GetIsActive(),
+		CreatedAt:     product.GetCreatedAt(),
+		UpdatedAt:     product.GetUpdatedAt(),
+	}
+}
+
+func toPriceResponse(price *pb.PriceEntry) dto.PriceResponse {
+	return dto.PriceResponse{
+		ProductID:     price.GetProductId(),
+		Price:         price.GetPrice(),
+		EffectiveFrom: price.GetEffectiveFrom(),
+		CreatedAt:     price.GetCreatedAt(),
+	}
+}
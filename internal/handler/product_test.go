@@ -0,0 +1,88 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/mlucas4330/orderflow-pro/internal/config"
+	"github.com/mlucas4330/orderflow-pro/internal/handler"
+	"github.com/mlucas4330/orderflow-pro/internal/middleware"
+	"github.com/mlucas4330/orderflow-pro/internal/repository"
+	pb "github.com/mlucas4330/orderflow-pro/pkg/productpb"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func generateTokenWithRoles(t *testing.T, userID uuid.UUID, jwtSecretKey string, roles ...string) string {
+	claims := jwt.MapClaims{
+		"sub":   userID.String(),
+		"exp":   time.Now().Add(time.Hour * 1).Unix(),
+		"roles": roles,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(jwtSecretKey))
+	require.NoError(t, err)
+	return tokenString
+}
+
+func TestCreateProductHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.LoadOrderConfig()
+
+	mockProductClient := new(repository.MockProductServiceClient)
+	mockProductClient.On("CreateProduct", mock.Anything, &pb.CreateProductRequest{Name: "Café", Sku: "CF-1", Price: "10.5", StockQuantity: 3}).
+		Return(&pb.Product{Id: uuid.NewString(), Sku: "CF-1", Price: "10.50", IsActive: true}, nil).Once()
+	mockProductClient.On("CreateProduct", mock.Anything, mock.Anything).
+		Return(nil, status.Error(codes.AlreadyExists, "já existe um produto com este SKU")).Once()
+
+	productHandler := handler.NewProductHandler(mockProductClient)
+	authMiddleware := middleware.NewAuthMiddleware(cfg.JWTSecretKey)
+
+	router := gin.New()
+	router.POST("/api/v1/products", authMiddleware, middleware.RequireScope(middleware.ScopeProductsManage), productHandler.CreateProduct)
+
+	body := []byte(`{"name":"Café","sku":"CF-1","price":"10.5","stock_quantity":3}`)
+	send := func(token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/products", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(generateTokenWithRoles(t, uuid.New(), cfg.JWTSecretKey, "customer"))
+	require.Equal(t, http.StatusForbidden, w.Code, "Apenas administradores podem cadastrar produtos")
+
+	admin := generateTokenWithRoles(t, uuid.New(), cfg.JWTSecretKey, middleware.RoleAdmin)
+
+	w = send(admin)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.Equal(t, true, created["is_active"])
+
+	w = send(admin)
+	require.Equal(t, http.StatusConflict, w.Code, "SKU duplicado deveria retornar 409")
+
+	for _, invalid := range []string{
+		`{"name":"Café","sku":"CF-2","stock_quantity":3}`,
+		`{"name":"Café","sku":"CF-2","price":"0","stock_quantity":3}`,
+		`{"name":"Café","sku":"CF-2","price":"-1","stock_quantity":3}`,
+	} {
+		body = []byte(invalid)
+		require.Equal(t, http.StatusBadRequest, send(admin).Code, "Preço ausente ou não positivo deveria ser rejeitado: %s", invalid)
+	}
+
+	mockProductClient.AssertExpectations(t)
+}
@@ -0,0 +1,68 @@
+package idempotency
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mlucas4330/orderflow-pro/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var purgedKeys = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "orderflow_idempotency_keys_purged_total",
+	Help: "Chaves de idempotência apagadas por expiração ou reserva abandonada.",
+})
+
+const defaultBatchSize = 500
+
+// Purger apaga periodicamente as chaves de idempotência cujo TTL passou, para
+// que a tabela não cresça para sempre.
+type Purger struct {
+	Repo      repository.IdempotencyRepository
+	Interval  time.Duration
+	BatchSize int
+}
+
+func NewPurger(repo repository.IdempotencyRepository, interval time.Duration) *Purger {
+	return &Purger{Repo: repo, Interval: interval, BatchSize: defaultBatchSize}
+}
+
+func (p *Purger) Run(ctx context.Context) {
+	log.Printf("Limpeza de chaves de idempotência iniciada (intervalo de %s).", p.Interval)
+
+	for {
+		purged, err := p.Purge(ctx)
+		if err != nil {
+			log.Printf("Erro ao apagar chaves de idempotência expiradas: %v", err)
+		}
+
+		// Lote cheio: pode haver mais chaves expiradas esperando.
+		if purged >= p.BatchSize && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Limpeza de chaves de idempotência finalizada.")
+			return
+		case <-time.After(p.Interval):
+		}
+	}
+}
+
+// Purge apaga um lote de chaves expiradas e devolve quantas foram apagadas.
+func (p *Purger) Purge(ctx context.Context) (int, error) {
+	purged, err := p.Repo.PurgeExpired(ctx, p.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	if purged > 0 {
+		log.Printf("%d chaves de idempotência expiradas apagadas.", purged)
+		purgedKeys.Add(float64(purged))
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,28 @@
+package idempotency_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mlucas4330/orderflow-pro/internal/idempotency"
+	"github.com/mlucas4330/orderflow-pro/internal/repository"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurge(t *testing.T) {
+	mockRepo := new(repository.MockIdempotencyRepository)
+	purger := idempotency.NewPurger(mockRepo, 0)
+
+	mockRepo.On("PurgeExpired", mock.Anything, 500).Return(12, nil).Once()
+	purged, err := purger.Purge(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 12, purged)
+
+	mockRepo.On("PurgeExpired", mock.Anything, 500).Return(0, errors.New("banco indisponível")).Once()
+	_, err = purger.Purge(context.Background())
+	require.Error(t, err)
+
+	mockRepo.AssertExpectations(t)
+}
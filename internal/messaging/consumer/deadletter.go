@@ -0,0 +1,55 @@
+package consumer
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+const (
+	HeaderDLQAttempts          = "dlq-attempts"
+	HeaderDLQLastError         = "dlq-last-error"
+	HeaderDLQOriginalTopic     = "dlq-original-topic"
+	HeaderDLQOriginalPartition = "dlq-original-partition"
+	HeaderDLQOriginalOffset    = "dlq-original-offset"
+	HeaderDLQFailedAt          = "dlq-failed-at"
+	HeaderDLQReplayedFrom      = "dlq-replayed-from"
+)
+
+const deadLetterHeaderPrefix = "dlq-"
+
+func DeadLetterTopic(topic string) string {
+	return topic + ".dlq"
+}
+
+func (c *KafkaConsumer) sendToDeadLetter(ctx context.Context, msg kafka.Message, attempts int, cause error) error {
+	headers := append(OriginalHeaders(msg),
+		kafka.Header{Key: HeaderDLQAttempts, Value: []byte(strconv.Itoa(attempts))},
+		kafka.Header{Key: HeaderDLQLastError, Value: []byte(cause.Error())},
+		kafka.Header{Key: HeaderDLQOriginalTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: HeaderDLQOriginalPartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: HeaderDLQOriginalOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		kafka.Header{Key: HeaderDLQFailedAt, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+	)
+
+	return c.deadLetter.WriteMessages(ctx, kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	})
+}
+
+// OriginalHeaders devolve os cabeçalhos da mensagem sem os metadados de DLQ,
+// para que uma mensagem reprocessada volte ao tópico original como foi publicada.
+func OriginalHeaders(msg kafka.Message) []kafka.Header {
+	headers := make([]kafka.Header, 0, len(msg.Headers))
+	for _, header := range msg.Headers {
+		if !strings.HasPrefix(header.Key, deadLetterHeaderPrefix) {
+			headers = append(headers, header)
+		}
+	}
+	return headers
+}
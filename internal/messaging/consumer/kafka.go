@@ -0,0 +1,164 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mlucas4330/orderflow-pro/internal/events"
+	kafka "github.com/segmentio/kafka-go"
+)
+
+type KafkaEventHandler func(ctx context.Context, msg kafka.Message) error
+
+type KafkaConsumer struct {
+	reader      *kafka.Reader
+	handlers    map[string]KafkaEventHandler
+	maxAttempts int
+	deadLetter  *kafka.Writer
+}
+
+const defaultMaxAttempts = 3
+
+func NewKafkaConsumer(kafkaBrokers string, topic string, groupID string) *KafkaConsumer {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     strings.Split(kafkaBrokers, ","),
+		Topic:       topic,
+		GroupID:     groupID,
+		Logger:      kafka.LoggerFunc(log.Printf),
+		ErrorLogger: kafka.LoggerFunc(log.Printf),
+	})
+
+	return &KafkaConsumer{
+		reader:      reader,
+		handlers:    make(map[string]KafkaEventHandler),
+		maxAttempts: defaultMaxAttempts,
+	}
+}
+
+// EnableDeadLetter faz com que mensagens que esgotarem as tentativas, ou que
+// falharem de forma permanente, sejam publicadas no tópico de dead-letter antes
+// do commit do offset.
+func (c *KafkaConsumer) EnableDeadLetter(kafkaBrokers string, topic string) {
+	c.deadLetter = &kafka.Writer{
+		Addr:     kafka.TCP(strings.Split(kafkaBrokers, ",")...),
+		Topic:    topic,
+		Balancer: &kafka.Hash{},
+	}
+}
+
+// Subscribe registra o handler para um tipo de evento. Mensagens de tipos sem
+// handler registrado são confirmadas e ignoradas.
+func (c *KafkaConsumer) Subscribe(eventType string, handler KafkaEventHandler) {
+	c.handlers[eventType] = handler
+}
+
+// Run consome mensagens até o contexto ser cancelado. Só retorna erro antes
+// disso se não conseguir gravar uma mensagem na DLQ: nesse caso o offset não é
+// confirmado e a mensagem volta a ser entregue quando o serviço reiniciar.
+func (c *KafkaConsumer) Run(ctx context.Context) error {
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return err
+			}
+			log.Printf("Erro ao buscar mensagem do Kafka: %v", err)
+			continue
+		}
+
+		eventType := EventType(msg)
+		if handler, ok := c.handlers[eventType]; ok {
+			attempts, err := c.handle(ctx, eventType, handler, msg)
+			if err != nil {
+				log.Printf("ERRO FINAL: Evento %s (partição %d, offset %d) falhou após %d tentativa(s): %v", eventType, msg.Partition, msg.Offset, attempts, err)
+
+				if c.deadLetter != nil {
+					if err := c.sendToDeadLetter(ctx, msg, attempts, err); err != nil {
+						return fmt.Errorf("falha ao enviar mensagem para a DLQ: %w", err)
+					}
+				}
+			}
+		}
+
+		if err := c.reader.CommitMessages(ctx, msg); err != nil {
+			log.Printf("Erro ao fazer commit da mensagem: %v", err)
+		}
+	}
+}
+
+func (c *KafkaConsumer) handle(ctx context.Context, eventType string, handler KafkaEventHandler, msg kafka.Message) (int, error) {
+	var err error
+
+	for attempt := 1; attempt <= c.maxAttempts; attempt++ {
+		err = handler(ctx, msg)
+		if err == nil {
+			return attempt, nil
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return attempt, err
+		}
+
+		log.Printf("AVISO: Falha na tentativa %d de processar o evento %s (offset %d): %v", attempt, eventType, msg.Offset, err)
+
+		if attempt < c.maxAttempts {
+			select {
+			case <-ctx.Done():
+				return attempt, ctx.Err()
+			case <-time.After(time.Duration(attempt) * time.Second):
+			}
+		}
+	}
+
+	return c.maxAttempts, err
+}
+
+func (c *KafkaConsumer) Close() error {
+	if c.deadLetter != nil {
+		if err := c.deadLetter.Close(); err != nil {
+			log.Printf("Erro ao fechar o produtor da DLQ: %v", err)
+		}
+	}
+	return c.reader.Close()
+}
+
+// EventType lê o tipo do evento do cabeçalho da mensagem. Mensagens publicadas
+// antes da introdução dos cabeçalhos são sempre eventos de pedido criado.
+func EventType(msg kafka.Message) string {
+	if value, ok := Header(msg, events.HeaderEventType); ok {
+		return value
+	}
+	return events.OrderCreatedType
+}
+
+func Header(msg kafka.Message, key string) (string, bool) {
+	for _, header := range msg.Headers {
+		if header.Key == key {
+			return string(header.Value), true
+		}
+	}
+	return "", false
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marca um erro que não se resolve com novas tentativas, como uma
+// mensagem malformada. A mensagem vai direto para a DLQ.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
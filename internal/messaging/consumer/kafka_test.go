@@ -0,0 +1,42 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mlucas4330/orderflow-pro/internal/events"
+	kafka "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventTypeDefaultsToOrderCreated(t *testing.T) {
+	require.Equal(t, events.OrderCreatedType, EventType(kafka.Message{}))
+
+	msg := kafka.Message{Headers: []kafka.Header{{Key: events.HeaderEventType, Value: []byte(events.OrderCancelledType)}}}
+	require.Equal(t, events.OrderCancelledType, EventType(msg))
+}
+
+func TestHandleStopsOnPermanentError(t *testing.T) {
+	c := &KafkaConsumer{maxAttempts: 3}
+
+	calls := 0
+	attempts, err := c.handle(context.Background(), events.OrderCreatedType, func(ctx context.Context, msg kafka.Message) error {
+		calls++
+		return Permanent(errors.New("json inválido"))
+	}, kafka.Message{})
+
+	require.Error(t, err)
+	require.Equal(t, 1, attempts)
+	require.Equal(t, 1, calls, "Erros permanentes não deveriam ser repetidos")
+}
+
+func TestOriginalHeadersDropsDeadLetterMetadata(t *testing.T) {
+	msg := kafka.Message{Headers: []kafka.Header{
+		{Key: events.HeaderEventType, Value: []byte(events.OrderCreatedType)},
+		{Key: HeaderDLQAttempts, Value: []byte("3")},
+		{Key: HeaderDLQLastError, Value: []byte("boom")},
+	}}
+
+	require.Equal(t, []kafka.Header{{Key: events.HeaderEventType, Value: []byte(events.OrderCreatedType)}}, OriginalHeaders(msg))
+}
@@ -0,0 +1,226 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mlucas4330/orderflow-pro/internal/events"
+	"github.com/mlucas4330/orderflow-pro/internal/messaging/producer"
+	"github.com/mlucas4330/orderflow-pro/internal/repository"
+	"github.com/mlucas4330/orderflow-pro/pkg/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	relayLag = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "orderflow_outbox_relay_lag_seconds",
+		Help: "Idade da mensagem pendente mais antiga do outbox.",
+	})
+	relayPublishDelay = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "orderflow_outbox_publish_delay_seconds",
+		Help:    "Tempo entre a gravação da mensagem no outbox e a sua publicação.",
+		Buckets: prometheus.ExponentialBuckets(0.05, 2, 14),
+	}, []string{"destination"})
+	relayMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "orderflow_outbox_messages_total",
+		Help: "Mensagens processadas pelo relay do outbox, por destino e resultado.",
+	}, []string{"destination", "result"})
+)
+
+const (
+	defaultBatchSize   = 100
+	defaultLease       = 30 * time.Second
+	defaultMaxAttempts = 10
+	maxBackoff         = 5 * time.Minute
+)
+
+type Relay struct {
+	Repo             repository.OutboxRepository
+	KafkaProducer    producer.IKafkaProducer
+	RabbitMQProducer producer.IRabbitMQProducer
+	Topics           []string
+	PollInterval     time.Duration
+	BatchSize        int
+	Lease            time.Duration
+	MaxAttempts      int
+}
+
+// NewRelay cria um relay que publica apenas as mensagens dos tópicos (ou filas)
+// informados, permitindo que cada serviço escoe o que ele mesmo grava no outbox.
+func NewRelay(repo repository.OutboxRepository, kafkaProducer producer.IKafkaProducer, rabbitProducer producer.IRabbitMQProducer, topics []string, pollInterval time.Duration) *Relay {
+	return &Relay{
+		Repo:             repo,
+		KafkaProducer:    kafkaProducer,
+		RabbitMQProducer: rabbitProducer,
+		Topics:           topics,
+		PollInterval:     pollInterval,
+		BatchSize:        defaultBatchSize,
+		Lease:            defaultLease,
+		MaxAttempts:      defaultMaxAttempts,
+	}
+}
+
+func (r *Relay) Run(ctx context.Context) {
+	log.Printf("Relay do outbox iniciado (intervalo de %s).", r.PollInterval)
+
+	for {
+		processed, err := r.ProcessBatch(ctx)
+		if err != nil {
+			log.Printf("Erro ao processar lote do outbox: %v", err)
+		}
+
+		r.updateLag(ctx)
+
+		if processed > 0 && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Relay do outbox finalizado.")
+			return
+		case <-time.After(r.PollInterval):
+		}
+	}
+}
+
+// ProcessBatch publica um lote de mensagens pendentes e devolve quantas foram
+// tratadas. Falhas de publicação são reagendadas com backoff exponencial e não
+// interrompem o restante do lote; depois de MaxAttempts a mensagem é estacionada.
+func (r *Relay) ProcessBatch(ctx context.Context) (int, error) {
+	messages, err := r.Repo.ClaimPending(ctx, r.Topics, r.BatchSize, r.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, msg := range messages {
+		destination := string(msg.Destination)
+
+		if err := r.publish(ctx, msg); err != nil {
+			if msg.Attempts+1 >= r.MaxAttempts {
+				log.Printf("AVISO: Mensagem %d (%s) do outbox estacionada após %d tentativas: %v", msg.ID, msg.EventType, msg.Attempts+1, err)
+				relayMessages.WithLabelValues(destination, "parked").Inc()
+
+				if err := r.Repo.MarkParked(ctx, msg.ID, err); err != nil {
+					log.Printf("Erro ao estacionar a mensagem %d do outbox: %v", msg.ID, err)
+				}
+				continue
+			}
+
+			retryAt := time.Now().Add(backoff(msg.Attempts + 1))
+			log.Printf("AVISO: Falha ao publicar mensagem %d (%s) do outbox, tentativa %d: %v", msg.ID, msg.EventType, msg.Attempts+1, err)
+			relayMessages.WithLabelValues(destination, "failed").Inc()
+
+			if err := r.Repo.MarkFailed(ctx, msg.ID, err, retryAt); err != nil {
+				log.Printf("Erro ao registrar falha da mensagem %d do outbox: %v", msg.ID, err)
+			}
+			continue
+		}
+
+		relayMessages.WithLabelValues(destination, "published").Inc()
+		relayPublishDelay.WithLabelValues(destination).Observe(time.Since(msg.CreatedAt).Seconds())
+
+		if err := r.Repo.MarkPublished(ctx, msg.ID); err != nil {
+			log.Printf("Erro ao marcar mensagem %d do outbox como publicada: %v", msg.ID, err)
+		}
+	}
+
+	return len(messages), nil
+}
+
+func (r *Relay) publish(ctx context.Context, msg model.OutboxMessage) error {
+	switch msg.Destination {
+	case model.OutboxDestinationKafka:
+		return r.publishKafka(ctx, msg)
+	case model.OutboxDestinationRabbitMQ:
+		return r.RabbitMQProducer.Publish(ctx, msg.Topic, msg.Payload)
+	default:
+		return fmt.Errorf("destino de outbox desconhecido: %s", msg.Destination)
+	}
+}
+
+func (r *Relay) publishKafka(ctx context.Context, msg model.OutboxMessage) error {
+	switch msg.EventType {
+	case events.OrderCreatedType:
+		var event events.OrderCreatedEvent
+		if err := decode(msg, &event); err != nil {
+			return err
+		}
+		return r.KafkaProducer.PublishOrderCreated(ctx, event)
+	case events.OrderStatusChangedType:
+		var event events.OrderStatusChangedEvent
+		if err := decode(msg, &event); err != nil {
+			return err
+		}
+		return r.KafkaProducer.PublishOrderStatusChanged(ctx, event)
+	case events.OrderCancelledType:
+		var event events.OrderCancelledEvent
+		if err := decode(msg, &event); err != nil {
+			return err
+		}
+		return r.KafkaProducer.PublishOrderCancelled(ctx, event)
+	case events.OrderRefundedType:
+		var event events.OrderRefundedEvent
+		if err := decode(msg, &event); err != nil {
+			return err
+		}
+		return r.KafkaProducer.PublishOrderRefunded(ctx, event)
+	case events.OrderDeletedType:
+		var event events.OrderDeletedEvent
+		if err := decode(msg, &event); err != nil {
+			return err
+		}
+		return r.KafkaProducer.PublishOrderDeleted(ctx, event)
+	case events.StockReservedType:
+		var event events.StockReservedEvent
+		if err := decode(msg, &event); err != nil {
+			return err
+		}
+		return r.KafkaProducer.PublishStockReserved(ctx, event)
+	case events.StockRejectedType:
+		var event events.StockRejectedEvent
+		if err := decode(msg, &event); err != nil {
+			return err
+		}
+		return r.KafkaProducer.PublishStockRejected(ctx, event)
+	case events.StockExpiredType:
+		var event events.StockExpiredEvent
+		if err := decode(msg, &event); err != nil {
+			return err
+		}
+		return r.KafkaProducer.PublishStockExpired(ctx, event)
+	default:
+		return fmt.Errorf("tipo de evento sem publicador no Kafka: %s", msg.EventType)
+	}
+}
+
+func (r *Relay) updateLag(ctx context.Context) {
+	oldest, err := r.Repo.OldestPendingCreatedAt(ctx, r.Topics)
+	if err != nil {
+		log.Printf("Erro ao calcular atraso do outbox: %v", err)
+		return
+	}
+
+	if oldest == nil {
+		relayLag.Set(0)
+		return
+	}
+
+	relayLag.Set(time.Since(*oldest).Seconds())
+}
+
+func decode(msg model.OutboxMessage, target any) error {
+	if err := json.Unmarshal(msg.Payload, target); err != nil {
+		return fmt.Errorf("erro ao desserializar mensagem %d do outbox para o agregado %s: %w", msg.ID, msg.AggregateID, err)
+	}
+	return nil
+}
+
+func backoff(attempt int) time.Duration {
+	delay := time.Second << min(attempt-1, 16)
+	return min(delay, maxBackoff)
+}
@@ -0,0 +1,56 @@
+package outbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mlucas4330/orderflow-pro/internal/events"
+	"github.com/mlucas4330/orderflow-pro/internal/messaging/outbox"
+	"github.com/mlucas4330/orderflow-pro/internal/repository"
+	"github.com/mlucas4330/orderflow-pro/pkg/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayProcessBatch(t *testing.T) {
+	mockRepo := new(repository.MockOutboxRepository)
+	mockKafka := new(repository.MockKafkaProducer)
+	mockRabbit := new(repository.MockRabbitMQProducer)
+
+	orderID := uuid.New()
+	event := events.OrderCreatedEvent{OrderID: orderID, CustomerID: uuid.New()}
+	payload, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	messages := []model.OutboxMessage{
+		{ID: 1, AggregateID: orderID, EventType: events.OrderCreatedType, Destination: model.OutboxDestinationKafka, Topic: "orders", Payload: payload, CreatedAt: time.Now()},
+		{ID: 2, AggregateID: uuid.New(), EventType: "notification.email", Destination: model.OutboxDestinationRabbitMQ, Topic: "email_notifications", Payload: []byte(`{}`), Attempts: 2, CreatedAt: time.Now()},
+		{ID: 3, AggregateID: uuid.New(), EventType: "tipo.desconhecido", Destination: model.OutboxDestinationKafka, Topic: "orders", Payload: []byte(`{}`), Attempts: 9, CreatedAt: time.Now()},
+	}
+
+	topics := []string{"orders", "email_notifications"}
+	mockRepo.On("ClaimPending", mock.Anything, topics, 100, 30*time.Second).Return(messages, nil)
+	mockKafka.On("PublishOrderCreated", mock.Anything, mock.MatchedBy(func(e events.OrderCreatedEvent) bool {
+		return e.OrderID == orderID
+	})).Return(nil)
+	mockRabbit.On("Publish", mock.Anything, "email_notifications", []byte(`{}`)).Return(errors.New("broker indisponível"))
+	mockRepo.On("MarkPublished", mock.Anything, int64(1)).Return(nil)
+	mockRepo.On("MarkFailed", mock.Anything, int64(2), mock.Anything, mock.MatchedBy(func(retryAt time.Time) bool {
+		return retryAt.After(time.Now().Add(3 * time.Second))
+	})).Return(nil)
+	mockRepo.On("MarkParked", mock.Anything, int64(3), mock.Anything).Return(nil)
+
+	relay := outbox.NewRelay(mockRepo, mockKafka, mockRabbit, topics, time.Second)
+
+	processed, err := relay.ProcessBatch(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, processed)
+
+	mockRepo.AssertExpectations(t)
+	mockKafka.AssertExpectations(t)
+	mockRabbit.AssertExpectations(t)
+}
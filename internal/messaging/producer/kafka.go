@@ -4,14 +4,23 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"strconv"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/mlucas4330/orderflow-pro/internal/events"
 	kafka "github.com/segmentio/kafka-go"
 )
 
 type IKafkaProducer interface {
 	PublishOrderCreated(context.Context, events.OrderCreatedEvent) error
+	PublishOrderStatusChanged(context.Context, events.OrderStatusChangedEvent) error
+	PublishOrderCancelled(context.Context, events.OrderCancelledEvent) error
+	PublishOrderRefunded(context.Context, events.OrderRefundedEvent) error
+	PublishOrderDeleted(context.Context, events.OrderDeletedEvent) error
+	PublishStockReserved(context.Context, events.StockReservedEvent) error
+	PublishStockRejected(context.Context, events.StockRejectedEvent) error
+	PublishStockExpired(context.Context, events.StockExpiredEvent) error
 	Close() error
 }
 
@@ -19,26 +28,65 @@ type KafkaProducer struct {
 	writer *kafka.Writer
 }
 
+// NewKafkaProducer particiona pela chave da mensagem (o ID do agregado), para
+// que os eventos de um mesmo pedido caiam na mesma partição e sejam
+// consumidos na ordem em que o outbox os publica.
 func NewKafkaProducer(kafkaBrokers string) *KafkaProducer {
 	writer := &kafka.Writer{
 		Addr:     kafka.TCP(strings.Split(kafkaBrokers, ",")...),
-		Topic:    "orders",
-		Balancer: &kafka.LeastBytes{},
+		Balancer: &kafka.Hash{},
 	}
 
 	return &KafkaProducer{writer: writer}
 }
 
 func (p *KafkaProducer) PublishOrderCreated(ctx context.Context, event events.OrderCreatedEvent) error {
+	return p.publish(ctx, events.OrdersTopic, event.OrderID, events.OrderCreatedType, 1, event)
+}
+
+func (p *KafkaProducer) PublishOrderStatusChanged(ctx context.Context, event events.OrderStatusChangedEvent) error {
+	return p.publish(ctx, events.OrdersTopic, event.OrderID, events.OrderStatusChangedType, event.Version, event)
+}
+
+func (p *KafkaProducer) PublishOrderCancelled(ctx context.Context, event events.OrderCancelledEvent) error {
+	return p.publish(ctx, events.OrdersTopic, event.OrderID, events.OrderCancelledType, event.Version, event)
+}
+
+func (p *KafkaProducer) PublishOrderRefunded(ctx context.Context, event events.OrderRefundedEvent) error {
+	return p.publish(ctx, events.OrdersTopic, event.OrderID, events.OrderRefundedType, event.Version, event)
+}
+
+func (p *KafkaProducer) PublishOrderDeleted(ctx context.Context, event events.OrderDeletedEvent) error {
+	return p.publish(ctx, events.OrdersTopic, event.OrderID, events.OrderDeletedType, event.Version, event)
+}
+
+func (p *KafkaProducer) PublishStockReserved(ctx context.Context, event events.StockReservedEvent) error {
+	return p.publish(ctx, events.InventoryTopic, event.OrderID, events.StockReservedType, event.Version, event)
+}
+
+func (p *KafkaProducer) PublishStockRejected(ctx context.Context, event events.StockRejectedEvent) error {
+	return p.publish(ctx, events.InventoryTopic, event.OrderID, events.StockRejectedType, event.Version, event)
+}
+
+func (p *KafkaProducer) PublishStockExpired(ctx context.Context, event events.StockExpiredEvent) error {
+	return p.publish(ctx, events.InventoryTopic, event.OrderID, events.StockExpiredType, event.Version, event)
+}
+
+func (p *KafkaProducer) publish(ctx context.Context, topic string, orderID uuid.UUID, eventType string, version int, event any) error {
 	msgValue, err := json.Marshal(event)
 	if err != nil {
-		log.Printf("Erro ao serializar evento OrderCreated: %v", err)
+		log.Printf("Erro ao serializar evento %s: %v", eventType, err)
 		return err
 	}
 
 	msg := kafka.Message{
-		Key:   []byte(event.OrderID.String()),
+		Topic: topic,
+		Key:   []byte(orderID.String()),
 		Value: msgValue,
+		Headers: []kafka.Header{
+			{Key: events.HeaderEventType, Value: []byte(eventType)},
+			{Key: events.HeaderEventVersion, Value: []byte(strconv.Itoa(version))},
+		},
 	}
 
 	err = p.writer.WriteMessages(ctx, msg)
@@ -47,7 +95,7 @@ func (p *KafkaProducer) PublishOrderCreated(ctx context.Context, event events.Or
 		return err
 	}
 
-	log.Printf("Evento OrderCreated publicado para o pedido: %s", event.OrderID)
+	log.Printf("Evento %s publicado para o pedido: %s", eventType, orderID)
 	return nil
 }
 
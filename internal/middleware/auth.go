@@ -1,8 +1,13 @@
 package middleware
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -11,7 +16,122 @@ import (
 	"github.com/google/uuid"
 )
 
-func NewAuthMiddleware(jwtSecretKey string) gin.HandlerFunc {
+const (
+	userIDKey = "userID"
+	rolesKey  = "roles"
+)
+
+const RoleAdmin = "admin"
+
+func UserIDFromContext(c *gin.Context) (uuid.UUID, bool) {
+	value, ok := c.Get(userIDKey)
+	if !ok {
+		return uuid.Nil, false
+	}
+	userID, ok := value.(uuid.UUID)
+	return userID, ok
+}
+
+// RolesFromContext devolve os papéis do token autenticado.
+func RolesFromContext(c *gin.Context) []string {
+	value, ok := c.Get(rolesKey)
+	if !ok {
+		return nil
+	}
+	roles, _ := value.([]string)
+	return roles
+}
+
+// HasRole informa se o token autenticado tem ao menos um dos papéis informados.
+func HasRole(c *gin.Context, roles ...string) bool {
+	for _, role := range RolesFromContext(c) {
+		if slices.Contains(roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireRole permite seguir apenas se o token tiver ao menos um dos papéis
+// informados. Deve ser registrado depois do middleware de autenticação.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if HasRole(c, roles...) {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "permissão insuficiente para esta operação"})
+	}
+}
+
+// parseRoles aceita a claim "roles" como lista ou string separada por espaços.
+func parseRoles(claims jwt.MapClaims) []string {
+	switch value := claims["roles"].(type) {
+	case string:
+		return strings.Fields(value)
+	case []any:
+		roles := make([]string, 0, len(value))
+		for _, item := range value {
+			if role, ok := item.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+		return roles
+	default:
+		return nil
+	}
+}
+
+type authOptions struct {
+	keySet   *JWKS
+	issuer   string
+	audience string
+}
+
+type AuthOption func(*authOptions)
+
+// WithJWKS habilita tokens RS256/ES256 (e variantes), validados pelas chaves
+// públicas do JWKS a partir do kid do cabeçalho.
+func WithJWKS(keySet *JWKS) AuthOption {
+	return func(o *authOptions) {
+		o.keySet = keySet
+	}
+}
+
+// WithIssuer exige que a claim "iss" seja igual ao emissor informado.
+func WithIssuer(issuer string) AuthOption {
+	return func(o *authOptions) {
+		o.issuer = issuer
+	}
+}
+
+// WithAudience exige que a claim "aud" contenha o público informado.
+func WithAudience(audience string) AuthOption {
+	return func(o *authOptions) {
+		o.audience = audience
+	}
+}
+
+// NewAuthMiddleware valida o token Bearer. Tokens HMAC usam jwtSecretKey (vazio
+// desabilita o HMAC) e os assimétricos usam o JWKS de WithJWKS, o que permite
+// aceitar os dois formatos durante a migração dos emissores.
+func NewAuthMiddleware(jwtSecretKey string, opts ...AuthOption) gin.HandlerFunc {
+	options := authOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	parserOptions := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"HS256", "HS384", "HS512", "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+	}
+	if options.issuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(options.issuer))
+	}
+	if options.audience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(options.audience))
+	}
+
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -28,11 +148,8 @@ func NewAuthMiddleware(jwtSecretKey string) gin.HandlerFunc {
 		tokenString := parts[1]
 
 		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("método de assinatura inesperado: %v", token.Header["alg"])
-			}
-			return []byte(jwtSecretKey), nil
-		})
+			return verificationKey(c.Request.Context(), token, jwtSecretKey, options.keySet)
+		}, parserOptions...)
 
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token inválido: " + err.Error()})
@@ -56,7 +173,10 @@ func NewAuthMiddleware(jwtSecretKey string) gin.HandlerFunc {
 					c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "ID de usuário no token é inválido"})
 					return
 				}
-				c.Set("userID", userID)
+				c.Set(userIDKey, userID)
+				roles := parseRoles(claims)
+				c.Set(rolesKey, roles)
+				c.Set(scopesKey, resolveScopes(claims, roles))
 				c.Next()
 				return
 			}
@@ -65,3 +185,32 @@ func NewAuthMiddleware(jwtSecretKey string) gin.HandlerFunc {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "claims do token inválidos"})
 	}
 }
+
+func verificationKey(ctx context.Context, token *jwt.Token, jwtSecretKey string, keySet *JWKS) (any, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if jwtSecretKey == "" {
+			return nil, errors.New("tokens HMAC não são aceitos")
+		}
+		return []byte(jwtSecretKey), nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS, *jwt.SigningMethodECDSA:
+		if keySet == nil {
+			return nil, fmt.Errorf("método de assinatura não habilitado: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		key, err := keySet.Key(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+
+		// Impede que uma chave seja usada com um algoritmo de outra família.
+		_, isRSA := key.(*rsa.PublicKey)
+		_, isEC := key.(*ecdsa.PublicKey)
+		if _, ecMethod := token.Method.(*jwt.SigningMethodECDSA); ecMethod != isEC || (!ecMethod && !isRSA) {
+			return nil, fmt.Errorf("chave %q incompatível com o algoritmo %v", kid, token.Header["alg"])
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("método de assinatura inesperado: %v", token.Header["alg"])
+	}
+}
@@ -0,0 +1,178 @@
+package middleware_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/mlucas4330/orderflow-pro/internal/middleware"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeInt(value *big.Int, size int) string {
+	data := value.Bytes()
+	if size > 0 {
+		data = value.FillBytes(make([]byte, size))
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func writeJWKS(t *testing.T, path string, keys map[string]any) {
+	var document struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	for kid, key := range keys {
+		switch key := key.(type) {
+		case *rsa.PublicKey:
+			document.Keys = append(document.Keys, map[string]string{
+				"kty": "RSA", "kid": kid, "use": "sig",
+				"n": encodeInt(key.N, 0), "e": encodeInt(big.NewInt(int64(key.E)), 0),
+			})
+		case *ecdsa.PublicKey:
+			document.Keys = append(document.Keys, map[string]string{
+				"kty": "EC", "kid": kid, "crv": "P-256",
+				"x": encodeInt(key.X, 32), "y": encodeInt(key.Y, 32),
+			})
+		}
+	}
+
+	data, err := json.Marshal(document)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestAuthMiddlewareWithJWKS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, map[string]any{"rsa-1": &rsaKey.PublicKey, "ec-1": &ecKey.PublicKey})
+
+	keySet, err := middleware.NewJWKS(context.Background(), path, time.Hour)
+	require.NoError(t, err)
+
+	const secret = "segredo-hmac"
+	router := gin.New()
+	router.GET("/", middleware.NewAuthMiddleware(secret,
+		middleware.WithJWKS(keySet),
+		middleware.WithIssuer("https://auth.orderflow.local"),
+		middleware.WithAudience("order-service"),
+	), func(c *gin.Context) {
+		userID, _ := middleware.UserIDFromContext(c)
+		c.String(http.StatusOK, userID.String())
+	})
+
+	send := func(token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	userID := uuid.New()
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		base := jwt.MapClaims{
+			"sub": userID.String(),
+			"exp": time.Now().Add(time.Hour).Unix(),
+			"iss": "https://auth.orderflow.local",
+			"aud": "order-service",
+		}
+		for key, value := range overrides {
+			base[key] = value
+		}
+		return base
+	}
+
+	w := send(signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(nil)))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, userID.String(), w.Body.String())
+
+	require.Equal(t, http.StatusOK, send(signToken(t, jwt.SigningMethodES256, "ec-1", ecKey, claims(nil))).Code)
+	require.Equal(t, http.StatusOK, send(signToken(t, jwt.SigningMethodHS256, "", []byte(secret), claims(nil))).Code, "Tokens HMAC deveriam continuar válidos durante a migração")
+
+	require.Equal(t, http.StatusUnauthorized, send(signToken(t, jwt.SigningMethodRS256, "ec-1", rsaKey, claims(nil))).Code, "Chave de outra família não deveria ser aceita")
+	require.Equal(t, http.StatusUnauthorized, send(signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(jwt.MapClaims{"iss": "https://outro"}))).Code)
+	require.Equal(t, http.StatusUnauthorized, send(signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(jwt.MapClaims{"aud": "product-service"}))).Code)
+
+	rotated, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writeJWKS(t, path, map[string]any{"rsa-2": &rotated.PublicKey})
+	keySet.MinRefreshInterval = 0
+
+	require.Equal(t, http.StatusOK, send(signToken(t, jwt.SigningMethodRS256, "rsa-2", rotated, claims(nil))).Code, "Um kid novo deveria recarregar o JWKS")
+}
+
+func TestJWKSServesKnownKeysWhileRefreshing(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, map[string]any{"rsa-1": &rsaKey.PublicKey})
+	document, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) > 1 {
+			<-release
+		}
+		w.Write(document)
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	keySet, err := middleware.NewJWKS(context.Background(), server.URL, time.Millisecond)
+	require.NoError(t, err)
+	keySet.MinRefreshInterval = 0
+	time.Sleep(5 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := keySet.Key(context.Background(), "rsa-1")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Uma chave conhecida não deveria esperar a recarga do JWKS")
+	}
+	require.Eventually(t, func() bool { return requests.Load() == 2 }, time.Second, 10*time.Millisecond, "O documento vencido deveria ser recarregado em segundo plano")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = keySet.Key(ctx, "rsa-novo")
+	require.ErrorIs(t, err, context.DeadlineExceeded, "Um kid desconhecido deveria esperar a recarga só até o prazo da requisição")
+}
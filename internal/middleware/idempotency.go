@@ -0,0 +1,253 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mlucas4330/orderflow-pro/internal/repository"
+	"github.com/mlucas4330/orderflow-pro/pkg/model"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	defaultIdempotencyLease  = 30 * time.Second
+	saveResponseAttempts     = 3
+	saveResponseBackoff      = 100 * time.Millisecond
+)
+
+// responseRecorder repassa a resposta ao cliente e guarda uma cópia do corpo
+// para que ela possa ser repetida depois.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// unsavedResponseBody é repetido quando a operação foi concluída mas a
+// resposta original não pôde ser guardada.
+const unsavedResponseBody = `{"error":"a operação foi concluída, mas a resposta original não pôde ser guardada"}`
+
+type idempotencyOptions struct {
+	lease time.Duration
+}
+
+type IdempotencyOption func(*idempotencyOptions)
+
+// WithIdempotencyLease define por quanto tempo a chave fica reservada sem
+// renovação; enquanto o handler roda, a reserva é renovada a cada terço desse
+// prazo.
+func WithIdempotencyLease(lease time.Duration) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.lease = lease
+	}
+}
+
+// NewIdempotencyMiddleware torna idempotente uma rota de escrita: a primeira
+// requisição com um Idempotency-Key reserva a chave, e a resposta (status,
+// cabeçalhos e corpo) é gravada por ttl e repetida para as retentativas. A
+// chave vale por usuário, então o middleware deve vir depois do de
+// autenticação. Requisições sem o cabeçalho seguem normalmente.
+func NewIdempotencyMiddleware(repo repository.IdempotencyRepository, ttl time.Duration, opts ...IdempotencyOption) gin.HandlerFunc {
+	options := idempotencyOptions{lease: defaultIdempotencyLease}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return func(c *gin.Context) {
+		rawKey := c.GetHeader(IdempotencyKeyHeader)
+		if rawKey == "" {
+			c.Next()
+			return
+		}
+
+		key, err := uuid.Parse(rawKey)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key deve ser um UUID"})
+			return
+		}
+
+		userID, ok := UserIDFromContext(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "usuário não autenticado"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "não foi possível ler o corpo da requisição"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		saved, err := repo.ClaimKey(ctx, key, userID, fingerprint(c.Request, body), options.lease)
+		switch {
+		case errors.Is(err, repository.ErrIdempotencyKeyMismatch):
+			// A mesma chave com outra requisição é erro do cliente; repetir a
+			// resposta antiga esconderia que a nova operação não foi feita.
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, repository.ErrIdempotencyKeyInProgress):
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			log.Printf("Erro ao reservar chave de idempotência: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "erro interno ao processar a requisição"})
+			return
+		case saved != nil:
+			log.Printf("HIT de idempotência para a chave: %s", key)
+			replay(c, saved)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		// Se o handler entrar em pânico ou a resposta não for definitiva, a chave
+		// é liberada para que o cliente possa tentar de novo.
+		release := true
+		defer func() {
+			if !release {
+				return
+			}
+			if err := repo.ReleaseKey(context.WithoutCancel(ctx), key, userID); err != nil {
+				log.Printf("Erro ao liberar chave de idempotência %s: %v", key, err)
+			}
+		}()
+
+		// A reserva é renovada enquanto o handler roda, para que uma requisição
+		// lenta não perca a chave para uma retentativa no meio da operação.
+		renewCtx, cancelRenewal := context.WithCancel(context.WithoutCancel(ctx))
+		renewed := make(chan struct{})
+		go func() {
+			defer close(renewed)
+			renewLease(renewCtx, repo, key, userID, options.lease)
+		}()
+		stopRenewal := func() {
+			cancelRenewal()
+			<-renewed
+		}
+		defer stopRenewal()
+
+		c.Next()
+		stopRenewal()
+
+		status := recorder.Status()
+		if !storable(status) {
+			return
+		}
+
+		// Daqui em diante a operação já foi feita, então a chave nunca é liberada.
+		// Se a resposta não puder ser guardada, a chave é concluída com uma
+		// resposta que só informa o resultado, em vez de ficar reservada até o
+		// lease vencer e deixar a retentativa repetir a operação.
+		release = false
+		response := &model.IdempotencyResponse{
+			StatusCode: status,
+			Headers:    recorder.Header().Clone(),
+			Body:       recorder.body.Bytes(),
+		}
+		if err := saveResponse(context.WithoutCancel(ctx), repo, key, userID, response, ttl); err != nil {
+			log.Printf("Erro ao salvar a resposta de idempotência %s, gravando resultado sem corpo: %v", key, err)
+			if err := saveResponse(context.WithoutCancel(ctx), repo, key, userID, unsavedResponse(response), ttl); err != nil {
+				log.Printf("AVISO CRÍTICO: Falha ao concluir a chave de idempotência %s: %v", key, err)
+			}
+		}
+	}
+}
+
+// renewLease estende a reserva da chave a cada terço do lease até ctx ser
+// cancelado.
+func renewLease(ctx context.Context, repo repository.IdempotencyRepository, key, userID uuid.UUID, lease time.Duration) {
+	ticker := time.NewTicker(lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := repo.RenewLease(ctx, key, userID, lease); err != nil && ctx.Err() == nil {
+				log.Printf("Erro ao renovar a chave de idempotência %s: %v", key, err)
+			}
+		}
+	}
+}
+
+// unsavedResponse mantém o status e o Location da resposta original, que
+// bastam para o cliente saber o resultado, e troca o corpo por um aviso.
+func unsavedResponse(original *model.IdempotencyResponse) *model.IdempotencyResponse {
+	headers := map[string][]string{"Content-Type": {"application/json; charset=utf-8"}}
+	if location := http.Header(original.Headers).Values("Location"); len(location) > 0 {
+		headers["Location"] = location
+	}
+	return &model.IdempotencyResponse{
+		StatusCode: original.StatusCode,
+		Headers:    headers,
+		Body:       []byte(unsavedResponseBody),
+	}
+}
+
+// saveResponse tenta gravar a resposta algumas vezes, já que uma falha
+// passageira do banco deixaria a chave sem resposta para repetir.
+func saveResponse(ctx context.Context, repo repository.IdempotencyRepository, key, userID uuid.UUID, response *model.IdempotencyResponse, ttl time.Duration) error {
+	var err error
+	for attempt := 1; attempt <= saveResponseAttempts; attempt++ {
+		if err = repo.SaveResponse(ctx, key, userID, response, ttl); err == nil {
+			return nil
+		}
+		if attempt < saveResponseAttempts {
+			time.Sleep(time.Duration(attempt) * saveResponseBackoff)
+		}
+	}
+	return err
+}
+
+// storable diz se a resposta é definitiva. Erros do servidor e conflitos
+// transitórios não são guardados, para que a retentativa execute de novo.
+func storable(status int) bool {
+	return status < http.StatusInternalServerError && status != http.StatusConflict && status != http.StatusTooManyRequests
+}
+
+func replay(c *gin.Context, saved *model.IdempotencyResponse) {
+	for name, values := range saved.Headers {
+		for _, value := range values {
+			c.Writer.Header().Add(name, value)
+		}
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Status(saved.StatusCode)
+	if len(saved.Body) > 0 {
+		c.Writer.Write(saved.Body)
+	}
+	c.Abort()
+}
+
+// fingerprint identifica a requisição pelo método, caminho e corpo, de modo que
+// a mesma chave não possa ser reaproveitada em outro endpoint.
+func fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
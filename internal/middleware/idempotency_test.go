@@ -0,0 +1,146 @@
+package middleware_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/mlucas4330/orderflow-pro/internal/middleware"
+	"github.com/mlucas4330/orderflow-pro/internal/repository"
+	"github.com/mlucas4330/orderflow-pro/pkg/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const secret = "segredo-hmac"
+	userID := uuid.New()
+	token := signToken(t, jwt.SigningMethodHS256, "", []byte(secret), jwt.MapClaims{
+		"sub": userID.String(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	mockRepo := new(repository.MockIdempotencyRepository)
+	savedKey, reusedKey, busyKey, newKey, failingKey, unsavedKey := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	mockRepo.On("ClaimKey", mock.Anything, savedKey, userID, mock.Anything, mock.Anything).Return(&model.IdempotencyResponse{
+		StatusCode: http.StatusCreated,
+		Headers:    map[string][]string{"Location": {"/api/v1/orders/salvo"}},
+		Body:       []byte(`{"id":"salvo"}`),
+	}, nil)
+	mockRepo.On("ClaimKey", mock.Anything, reusedKey, userID, mock.Anything, mock.Anything).Return(nil, repository.ErrIdempotencyKeyMismatch)
+	mockRepo.On("ClaimKey", mock.Anything, busyKey, userID, mock.Anything, mock.Anything).Return(nil, repository.ErrIdempotencyKeyInProgress)
+	mockRepo.On("ClaimKey", mock.Anything, newKey, userID, mock.Anything, mock.Anything).Return(nil, nil)
+	mockRepo.On("SaveResponse", mock.Anything, newKey, userID, mock.MatchedBy(func(res *model.IdempotencyResponse) bool {
+		return res.StatusCode == http.StatusCreated &&
+			string(res.Body) == `{"id":"novo"}` &&
+			res.Headers["Location"][0] == "/api/v1/orders/novo"
+	}), 2*time.Hour).Return(nil)
+	mockRepo.On("ClaimKey", mock.Anything, failingKey, userID, mock.Anything, mock.Anything).Return(nil, nil)
+	mockRepo.On("ReleaseKey", mock.Anything, failingKey, userID).Return(nil)
+	mockRepo.On("ClaimKey", mock.Anything, unsavedKey, userID, mock.Anything, mock.Anything).Return(nil, nil)
+	mockRepo.On("SaveResponse", mock.Anything, unsavedKey, userID, mock.MatchedBy(func(res *model.IdempotencyResponse) bool {
+		return string(res.Body) == `{"id":"novo"}`
+	}), 2*time.Hour).Return(errors.New("resposta grande demais"))
+	mockRepo.On("SaveResponse", mock.Anything, unsavedKey, userID, mock.MatchedBy(func(res *model.IdempotencyResponse) bool {
+		return res.StatusCode == http.StatusCreated &&
+			res.Headers["Location"][0] == "/api/v1/orders/novo" &&
+			string(res.Body) != `{"id":"novo"}`
+	}), 2*time.Hour).Return(nil).Once()
+
+	calls := 0
+	router := gin.New()
+	router.POST("/", middleware.NewAuthMiddleware(secret), middleware.NewIdempotencyMiddleware(mockRepo, 2*time.Hour), func(c *gin.Context) {
+		calls++
+		if c.GetHeader(middleware.IdempotencyKeyHeader) == failingKey.String() {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "serviço indisponível"})
+			return
+		}
+		c.Header("Location", "/api/v1/orders/novo")
+		c.Data(http.StatusCreated, "application/json", []byte(`{"id":"novo"}`))
+	})
+
+	send := func(key string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"items":[]}`)))
+		req.Header.Set("Authorization", "Bearer "+token)
+		if key != "" {
+			req.Header.Set(middleware.IdempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(savedKey.String())
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.JSONEq(t, `{"id":"salvo"}`, w.Body.String())
+	require.Equal(t, "/api/v1/orders/salvo", w.Header().Get("Location"))
+	require.Equal(t, "true", w.Header().Get(middleware.IdempotentReplayedHeader))
+	require.Zero(t, calls, "A resposta repetida não deveria executar o handler")
+
+	require.Equal(t, http.StatusUnprocessableEntity, send(reusedKey.String()).Code, "Reusar a chave com outra requisição deveria ser rejeitado")
+	require.Equal(t, http.StatusConflict, send(busyKey.String()).Code, "Chave em uso por outra requisição deveria responder 409")
+	require.Equal(t, http.StatusBadRequest, send("nao-e-uuid").Code)
+	require.Zero(t, calls)
+
+	require.Equal(t, http.StatusCreated, send(newKey.String()).Code)
+	require.Equal(t, http.StatusBadGateway, send(failingKey.String()).Code, "Erro do servidor deveria liberar a chave para nova tentativa")
+	require.Equal(t, http.StatusCreated, send("").Code, "Requisições sem chave deveriam seguir normalmente")
+	require.Equal(t, 3, calls)
+
+	// Sem a resposta original, a chave é concluída com o resultado em vez de
+	// ficar reservada.
+	require.Equal(t, http.StatusCreated, send(unsavedKey.String()).Code)
+	mockRepo.AssertNumberOfCalls(t, "SaveResponse", 5)
+	mockRepo.AssertNotCalled(t, "ReleaseKey", mock.Anything, unsavedKey, userID)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIdempotencyMiddlewareRenewsLease(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const secret = "segredo-hmac"
+	userID := uuid.New()
+	token := signToken(t, jwt.SigningMethodHS256, "", []byte(secret), jwt.MapClaims{
+		"sub": userID.String(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	key := uuid.New()
+	lease := 30 * time.Millisecond
+	mockRepo := new(repository.MockIdempotencyRepository)
+	mockRepo.On("ClaimKey", mock.Anything, key, userID, mock.Anything, lease).Return(nil, nil)
+	var renewals atomic.Int32
+	mockRepo.On("RenewLease", mock.Anything, key, userID, lease).Return(nil).Run(func(mock.Arguments) { renewals.Add(1) })
+	mockRepo.On("SaveResponse", mock.Anything, key, userID, mock.Anything, time.Hour).Return(nil)
+
+	router := gin.New()
+	router.POST("/", middleware.NewAuthMiddleware(secret), middleware.NewIdempotencyMiddleware(mockRepo, time.Hour, middleware.WithIdempotencyLease(lease)), func(c *gin.Context) {
+		time.Sleep(5 * lease)
+		c.Status(http.StatusCreated)
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{}`)))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set(middleware.IdempotencyKeyHeader, key.String())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	mockRepo.AssertExpectations(t)
+	renewed := renewals.Load()
+	require.GreaterOrEqual(t, renewed, int32(2), "A reserva deveria ser renovada enquanto o handler roda")
+
+	time.Sleep(3 * lease)
+	require.Equal(t, renewed, renewals.Load(), "A renovação deveria parar quando o handler termina")
+}
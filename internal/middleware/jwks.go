@@ -0,0 +1,275 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const defaultMinJWKSRefresh = 10 * time.Second
+
+var ErrUnknownKey = errors.New("chave de assinatura desconhecida")
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// JWKS mantém em memória as chaves públicas de um documento JWKS, lido de um
+// arquivo ou de uma URL HTTP. O documento é recarregado quando fica mais velho
+// que RefreshInterval ou quando chega um token com kid ainda não conhecido,
+// o que cobre a rotação de chaves do emissor. MinRefreshInterval limita essas
+// recargas, para que tokens forjados não virem uma enxurrada de requisições ao
+// emissor. A recarga roda fora do mutex: enquanto ela acontece, as chaves já
+// conhecidas continuam sendo servidas.
+type JWKS struct {
+	Source             string
+	RefreshInterval    time.Duration
+	MinRefreshInterval time.Duration
+	Client             *http.Client
+
+	group       singleflight.Group
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+// NewJWKS carrega o documento uma primeira vez; uma origem inválida falha já
+// na inicialização do serviço.
+func NewJWKS(ctx context.Context, source string, refreshInterval time.Duration) (*JWKS, error) {
+	keySet := &JWKS{
+		Source:             source,
+		RefreshInterval:    refreshInterval,
+		MinRefreshInterval: defaultMinJWKSRefresh,
+		Client:             &http.Client{Timeout: 5 * time.Second},
+		lastAttempt:        time.Now(),
+	}
+
+	if err := keySet.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	return keySet, nil
+}
+
+// Key devolve a chave pública do kid informado. Tokens sem kid só são aceitos
+// quando o documento tem uma única chave. Um documento vencido é recarregado em
+// segundo plano; só um kid desconhecido espera pela recarga.
+func (k *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	k.mu.Lock()
+	stale := time.Since(k.fetchedAt) > k.RefreshInterval
+	due := time.Since(k.lastAttempt) >= k.MinRefreshInterval
+	key, known := k.lookup(kid)
+	k.mu.Unlock()
+
+	if known {
+		if stale && due {
+			go k.reload(context.WithoutCancel(ctx))
+		}
+		return key, nil
+	}
+
+	if due {
+		result := k.group.DoChan("refresh", func() (any, error) {
+			k.reload(context.WithoutCancel(ctx))
+			return nil, nil
+		})
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-result:
+		}
+
+		k.mu.Lock()
+		key, known = k.lookup(kid)
+		k.mu.Unlock()
+	}
+
+	if !known {
+		return nil, fmt.Errorf("%w: kid %q", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+// reload recarrega o documento, no máximo uma vez por MinRefreshInterval, e
+// mantém as chaves atuais se a recarga falhar.
+func (k *JWKS) reload(ctx context.Context) {
+	k.mu.Lock()
+	if time.Since(k.lastAttempt) < k.MinRefreshInterval {
+		k.mu.Unlock()
+		return
+	}
+	k.lastAttempt = time.Now()
+	k.mu.Unlock()
+
+	if err := k.refresh(ctx); err != nil {
+		log.Printf("AVISO: Falha ao recarregar JWKS de %s, mantendo as chaves atuais: %v", k.Source, err)
+	}
+}
+
+// lookup deve ser chamado com o mutex travado.
+func (k *JWKS) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// refresh busca o documento sem travar o mutex, que só protege a troca das
+// chaves no final.
+func (k *JWKS) refresh(ctx context.Context) error {
+	data, err := k.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("documento JWKS inválido: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(document.Keys))
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			log.Printf("AVISO: Ignorando chave %q do JWKS: %v", jwk.Kid, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return errors.New("documento JWKS sem chaves de assinatura utilizáveis")
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys = keys
+	k.fetchedAt = time.Now()
+	return nil
+}
+
+func (k *JWKS) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(k.Source, "http://") && !strings.HasPrefix(k.Source, "https://") {
+		data, err := os.ReadFile(strings.TrimPrefix(k.Source, "file://"))
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler arquivo JWKS: %w", err)
+		}
+		return data, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.Source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao montar requisição do JWKS: %w", err)
+	}
+
+	res, err := k.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar JWKS: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("resposta inesperada ao buscar JWKS: %s", res.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(res.Body, 1<<20))
+}
+
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("expoente RSA inválido")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		var ecdhCurve ecdh.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+		case "P-384":
+			curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+		case "P-521":
+			curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+		default:
+			return nil, fmt.Errorf("curva não suportada: %s", jwk.Crv)
+		}
+
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		// Confere se o ponto pertence à curva antes de aceitar a chave.
+		size := (curve.Params().BitSize + 7) / 8
+		point := make([]byte, 1+2*size)
+		point[0] = 4
+		if x.BitLen() > size*8 || y.BitLen() > size*8 {
+			return nil, errors.New("coordenadas EC inválidas")
+		}
+		x.FillBytes(point[1 : 1+size])
+		y.FillBytes(point[1+size:])
+		if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+			return nil, fmt.Errorf("ponto EC inválido: %w", err)
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("tipo de chave não suportado: %s", jwk.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("parâmetro de chave inválido")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,59 @@
+package pricing
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mlucas4330/orderflow-pro/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var appliedPrices = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "orderflow_product_prices_applied_total",
+	Help: "Preços programados aplicados ao catálogo.",
+})
+
+// Scheduler aplica periodicamente os preços programados cuja vigência já
+// começou, mantendo products.price igual ao preço vigente do histórico.
+type Scheduler struct {
+	Repo     repository.ProductRepository
+	Interval time.Duration
+}
+
+func NewScheduler(repo repository.ProductRepository, interval time.Duration) *Scheduler {
+	return &Scheduler{Repo: repo, Interval: interval}
+}
+
+func (s *Scheduler) Run(ctx context.Context) {
+	log.Printf("Agendador de preços iniciado (intervalo de %s).", s.Interval)
+
+	for {
+		if _, err := s.Apply(ctx); err != nil {
+			log.Printf("Erro ao aplicar preços programados: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Agendador de preços finalizado.")
+			return
+		case <-time.After(s.Interval):
+		}
+	}
+}
+
+// Apply aplica os preços vencidos e devolve quantos produtos mudaram de preço.
+func (s *Scheduler) Apply(ctx context.Context) (int, error) {
+	applied, err := s.Repo.ApplyDuePrices(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if applied > 0 {
+		log.Printf("%d preços programados aplicados ao catálogo.", applied)
+		appliedPrices.Add(float64(applied))
+	}
+
+	return applied, nil
+}
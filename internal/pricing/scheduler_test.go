@@ -0,0 +1,24 @@
+package pricing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mlucas4330/orderflow-pro/internal/pricing"
+	"github.com/mlucas4330/orderflow-pro/internal/repository"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulerApply(t *testing.T) {
+	mockRepo := new(repository.MockProductRepository)
+	scheduler := pricing.NewScheduler(mockRepo, 0)
+
+	mockRepo.On("ApplyDuePrices", mock.Anything).Return(2, nil).Once()
+
+	applied, err := scheduler.Apply(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, applied)
+
+	mockRepo.AssertExpectations(t)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	pgx "github.com/jackc/pgx/v5"
@@ -12,10 +13,20 @@ import (
 )
 
 type IdempotencyRepository interface {
-	GetResponse(ctx context.Context, key uuid.UUID, userID uuid.UUID) (*model.IdempotencyResponse, error)
-	SaveResponse(ctx context.Context, key uuid.UUID, userID uuid.UUID, response *model.IdempotencyResponse) error
+	ClaimKey(ctx context.Context, key uuid.UUID, userID uuid.UUID, requestHash string, lease time.Duration) (*model.IdempotencyResponse, error)
+	SaveResponse(ctx context.Context, key uuid.UUID, userID uuid.UUID, response *model.IdempotencyResponse, ttl time.Duration) error
+	RenewLease(ctx context.Context, key uuid.UUID, userID uuid.UUID, lease time.Duration) error
+	ReleaseKey(ctx context.Context, key uuid.UUID, userID uuid.UUID) error
+	PurgeExpired(ctx context.Context, limit int) (int, error)
 }
 
+var (
+	ErrIdempotencyKeyInProgress = errors.New("outra requisição com a mesma chave de idempotência ainda está em andamento")
+	ErrIdempotencyKeyMismatch   = errors.New("a chave de idempotência já foi usada com outra requisição")
+)
+
+const idempotencyProcessing = "processing"
+
 type PostgresIdempotencyRepository struct {
 	DB *pgxpool.Pool
 }
@@ -24,36 +35,135 @@ func NewIdempotencyRepository(dbpool *pgxpool.Pool) *PostgresIdempotencyReposito
 	return &PostgresIdempotencyRepository{DB: dbpool}
 }
 
-func (r *PostgresIdempotencyRepository) GetResponse(ctx context.Context, key uuid.UUID, userID uuid.UUID) (*model.IdempotencyResponse, error) {
-	query := `
-		SELECT response_status_code, response_body 
-		FROM idempotency_keys 
+// ClaimKey reserva a chave para a requisição atual antes de qualquer trabalho.
+// Devolve (nil, nil) quando a chave foi reservada, a resposta salva quando a
+// chave já foi concluída, ErrIdempotencyKeyInProgress se outra requisição a
+// detém e ErrIdempotencyKeyMismatch se ela foi usada com outra requisição.
+// Reservas cujo lease venceu (processo que caiu no meio) e respostas já
+// expiradas podem ser retomadas.
+func (r *PostgresIdempotencyRepository) ClaimKey(ctx context.Context, key uuid.UUID, userID uuid.UUID, requestHash string, lease time.Duration) (*model.IdempotencyResponse, error) {
+	claimQuery := `
+		INSERT INTO idempotency_keys (idempotency_key, user_id, request_hash, status, locked_until)
+		VALUES ($1, $2, $3, 'processing', now() + $4::interval)
+		ON CONFLICT (user_id, idempotency_key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, status = 'processing', locked_until = EXCLUDED.locked_until,
+			response_status_code = NULL, response_headers = NULL, response_body = NULL, expires_at = NULL, created_at = now()
+		WHERE (idempotency_keys.status = 'processing' AND idempotency_keys.locked_until < now())
+			OR (idempotency_keys.status = 'completed' AND idempotency_keys.expires_at < now())
+		RETURNING true
+	`
+	existingQuery := `
+		SELECT status, COALESCE(request_hash, ''), response_status_code, response_headers, response_body
+		FROM idempotency_keys
 		WHERE idempotency_key = $1 AND user_id = $2
 	`
 
-	var res model.IdempotencyResponse
+	// A chave pode ser liberada entre o INSERT e o SELECT; nesse caso tenta de novo.
+	for range 3 {
+		var claimed bool
+		err := r.DB.QueryRow(ctx, claimQuery, key, userID, requestHash, lease).Scan(&claimed)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("erro ao reservar chave de idempotência: %w", err)
+		}
 
-	err := r.DB.QueryRow(ctx, query, key, userID).Scan(&res.StatusCode, &res.Body)
-	if err != nil {
+		var status string
+		var statusCode *int
+		var res model.IdempotencyResponse
+		err = r.DB.QueryRow(ctx, existingQuery, key, userID).Scan(&status, &res.RequestHash, &statusCode, &res.Headers, &res.Body)
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, nil
+			continue
 		}
-		return nil, fmt.Errorf("erro ao buscar chave de idempotência: %w", err)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao buscar chave de idempotência: %w", err)
+		}
+
+		if res.RequestHash != "" && res.RequestHash != requestHash {
+			return nil, ErrIdempotencyKeyMismatch
+		}
+		if status == idempotencyProcessing || statusCode == nil {
+			return nil, ErrIdempotencyKeyInProgress
+		}
+
+		res.StatusCode = *statusCode
+		return &res, nil
 	}
 
-	return &res, nil
+	return nil, ErrIdempotencyKeyInProgress
 }
 
-func (r *PostgresIdempotencyRepository) SaveResponse(ctx context.Context, key uuid.UUID, userID uuid.UUID, response *model.IdempotencyResponse) error {
+// SaveResponse conclui a reserva feita por ClaimKey gravando a resposta, que
+// fica disponível para repetição por ttl.
+func (r *PostgresIdempotencyRepository) SaveResponse(ctx context.Context, key uuid.UUID, userID uuid.UUID, response *model.IdempotencyResponse, ttl time.Duration) error {
 	query := `
-		INSERT INTO idempotency_keys (idempotency_key, user_id, response_status_code, response_body)
-		VALUES ($1, $2, $3, $4)
+		UPDATE idempotency_keys
+		SET status = 'completed', response_status_code = $3, response_headers = $4, response_body = $5,
+			locked_until = NULL, expires_at = now() + $6::interval
+		WHERE idempotency_key = $1 AND user_id = $2 AND status = 'processing'
 	`
 
-	_, err := r.DB.Exec(ctx, query, key, userID, response.StatusCode, response.Body)
+	tag, err := r.DB.Exec(ctx, query, key, userID, response.StatusCode, response.Headers, response.Body, ttl)
 	if err != nil {
 		return fmt.Errorf("erro ao salvar chave de idempotência: %w", err)
 	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("chave de idempotência %s não está reservada", key)
+	}
+
+	return nil
+}
+
+// RenewLease estende por lease a reserva feita por ClaimKey enquanto a
+// requisição ainda está em andamento.
+func (r *PostgresIdempotencyRepository) RenewLease(ctx context.Context, key uuid.UUID, userID uuid.UUID, lease time.Duration) error {
+	query := `
+		UPDATE idempotency_keys SET locked_until = now() + $3::interval
+		WHERE idempotency_key = $1 AND user_id = $2 AND status = 'processing'
+	`
+
+	tag, err := r.DB.Exec(ctx, query, key, userID, lease)
+	if err != nil {
+		return fmt.Errorf("erro ao renovar chave de idempotência: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("chave de idempotência %s não está reservada", key)
+	}
+
+	return nil
+}
+
+// ReleaseKey desfaz uma reserva que não chegou a uma resposta definitiva, para
+// que o cliente possa tentar de novo com a mesma chave.
+func (r *PostgresIdempotencyRepository) ReleaseKey(ctx context.Context, key uuid.UUID, userID uuid.UUID) error {
+	query := `DELETE FROM idempotency_keys WHERE idempotency_key = $1 AND user_id = $2 AND status = 'processing'`
+
+	if _, err := r.DB.Exec(ctx, query, key, userID); err != nil {
+		return fmt.Errorf("erro ao liberar chave de idempotência: %w", err)
+	}
 
 	return nil
 }
+
+// PurgeExpired apaga até limit chaves cujas respostas expiraram ou cujas
+// reservas foram abandonadas, e devolve quantas foram apagadas.
+func (r *PostgresIdempotencyRepository) PurgeExpired(ctx context.Context, limit int) (int, error) {
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE (user_id, idempotency_key) IN (
+			SELECT user_id, idempotency_key
+			FROM idempotency_keys
+			WHERE (status = 'completed' AND expires_at < now())
+				OR (status = 'processing' AND locked_until < now())
+			LIMIT $1
+		)
+	`
+
+	tag, err := r.DB.Exec(ctx, query, limit)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao apagar chaves de idempotência expiradas: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mlucas4330/orderflow-pro/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimIdempotencyKey(t *testing.T) {
+	_, dbpool, redisClient := setupTest(t)
+	t.Cleanup(func() {
+		_, err := dbpool.Exec(context.Background(), "TRUNCATE TABLE idempotency_keys")
+		require.NoError(t, err)
+		dbpool.Close()
+		redisClient.Close()
+	})
+
+	repo := NewIdempotencyRepository(dbpool)
+	ctx := context.Background()
+	key, userID := uuid.New(), uuid.New()
+
+	saved, err := repo.ClaimKey(ctx, key, userID, "hash-a", time.Minute)
+	require.NoError(t, err)
+	require.Nil(t, saved)
+
+	_, err = repo.ClaimKey(ctx, key, userID, "hash-a", time.Minute)
+	require.ErrorIs(t, err, ErrIdempotencyKeyInProgress, "A chave reservada não deveria ser concedida duas vezes")
+
+	_, err = repo.ClaimKey(ctx, key, userID, "hash-b", time.Minute)
+	require.ErrorIs(t, err, ErrIdempotencyKeyMismatch)
+
+	saved, err = repo.ClaimKey(ctx, key, uuid.New(), "hash-b", time.Minute)
+	require.NoError(t, err, "A mesma chave de outro usuário é independente")
+	require.Nil(t, saved)
+
+	err = repo.SaveResponse(ctx, key, userID, &model.IdempotencyResponse{
+		StatusCode: http.StatusCreated,
+		Headers:    map[string][]string{"Location": {"/api/v1/orders/1"}},
+		Body:       []byte(`{}`),
+	}, time.Hour)
+	require.NoError(t, err)
+
+	saved, err = repo.ClaimKey(ctx, key, userID, "hash-a", time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, saved.StatusCode)
+	require.Equal(t, []string{"/api/v1/orders/1"}, saved.Headers["Location"])
+
+	crashed := uuid.New()
+	_, err = repo.ClaimKey(ctx, crashed, userID, "hash-c", -time.Second)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.RenewLease(ctx, crashed, userID, time.Minute))
+	_, err = repo.ClaimKey(ctx, crashed, userID, "hash-c", time.Minute)
+	require.ErrorIs(t, err, ErrIdempotencyKeyInProgress, "Reserva renovada não deveria ser retomada")
+	require.NoError(t, repo.RenewLease(ctx, crashed, userID, -time.Second))
+
+	saved, err = repo.ClaimKey(ctx, crashed, userID, "hash-c", time.Minute)
+	require.NoError(t, err, "Reserva com lease vencido deveria ser retomada")
+	require.Nil(t, saved)
+
+	require.NoError(t, repo.ReleaseKey(ctx, crashed, userID))
+	saved, err = repo.ClaimKey(ctx, crashed, userID, "hash-d", time.Minute)
+	require.NoError(t, err, "Chave liberada deveria aceitar uma nova requisição")
+	require.Nil(t, saved)
+
+	require.Error(t, repo.RenewLease(ctx, key, userID, time.Minute), "Chave concluída não deveria ser renovada")
+}
+
+func TestPurgeExpiredIdempotencyKeys(t *testing.T) {
+	_, dbpool, redisClient := setupTest(t)
+	t.Cleanup(func() {
+		_, err := dbpool.Exec(context.Background(), "TRUNCATE TABLE idempotency_keys")
+		require.NoError(t, err)
+		dbpool.Close()
+		redisClient.Close()
+	})
+
+	repo := NewIdempotencyRepository(dbpool)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	expired, live := uuid.New(), uuid.New()
+	for _, key := range []uuid.UUID{expired, live} {
+		_, err := repo.ClaimKey(ctx, key, userID, "hash", time.Minute)
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.SaveResponse(ctx, expired, userID, &model.IdempotencyResponse{StatusCode: http.StatusNoContent}, -time.Second))
+	require.NoError(t, repo.SaveResponse(ctx, live, userID, &model.IdempotencyResponse{StatusCode: http.StatusNoContent}, time.Hour))
+
+	abandoned := uuid.New()
+	_, err := repo.ClaimKey(ctx, abandoned, userID, "hash", -time.Second)
+	require.NoError(t, err)
+
+	purged, err := repo.PurgeExpired(ctx, 100)
+	require.NoError(t, err)
+	require.Equal(t, 2, purged)
+
+	saved, err := repo.ClaimKey(ctx, live, userID, "hash", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, saved, "Resposta ainda válida não deveria ser apagada")
+}
@@ -2,32 +2,611 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	pgx "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mlucas4330/orderflow-pro/internal/events"
+	"github.com/mlucas4330/orderflow-pro/pkg/model"
 )
 
 type InventoryRepository interface {
 	DecrementStock(ctx context.Context, productId uuid.UUID, quantity int) error
+	IncrementStock(ctx context.Context, productId uuid.UUID, quantity int) error
+	ReserveItems(ctx context.Context, orderID uuid.UUID, items []events.OrderItemCreated) error
+	CommitReservation(ctx context.Context, orderID, customerID uuid.UUID) (StockOutcome, error)
+	RestockOrder(ctx context.Context, orderID uuid.UUID, reason string) (bool, error)
+	ExpireReservations(ctx context.Context, limit int) (int, error)
+	ProcessOrderCreated(ctx context.Context, event events.OrderCreatedEvent) (StockOutcome, error)
 }
 
+type StockOutcome string
+
+const (
+	StockOutcomeReserved  StockOutcome = "reserved"
+	StockOutcomeCommitted StockOutcome = "committed"
+	StockOutcomeRejected  StockOutcome = "rejected"
+	StockOutcomeDuplicate StockOutcome = "duplicate"
+)
+
+const inventoryConsumer = "inventory-service"
+
+// checkViolation é o SQLSTATE de violação de CHECK, disparado pela constraint
+// stock_quantity >= 0 quando não há estoque suficiente.
+const checkViolation = "23514"
+
+var ErrInsufficientStock = errors.New("estoque insuficiente")
+
+// InsufficientStockError lista todos os itens que impediram a reserva de um
+// pedido. Satisfaz errors.Is(err, ErrInsufficientStock).
+type InsufficientStockError struct {
+	OrderID   uuid.UUID
+	Shortages []events.StockShortage
+}
+
+func (e *InsufficientStockError) Error() string {
+	parts := make([]string, 0, len(e.Shortages))
+	for _, shortage := range e.Shortages {
+		if shortage.SKU == "" {
+			parts = append(parts, fmt.Sprintf("produto %s inexistente", shortage.ProductID))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s (solicitado %d, disponível %d)", shortage.SKU, shortage.Requested, shortage.Available))
+	}
+	return fmt.Sprintf("estoque insuficiente para o pedido %s: %s", e.OrderID, strings.Join(parts, ", "))
+}
+
+func (e *InsufficientStockError) Is(target error) bool {
+	return target == ErrInsufficientStock
+}
+
+type ReservationStatus string
+
+const (
+	ReservationActive    ReservationStatus = "active"
+	ReservationCommitted ReservationStatus = "committed"
+	ReservationReleased  ReservationStatus = "released"
+	ReservationExpired   ReservationStatus = "expired"
+)
+
 type PostgresInventoryRepository struct {
-	DB *pgxpool.Pool
+	DB             *pgxpool.Pool
+	ReservationTTL time.Duration
 }
 
-func NewInventoryRepository(dbpool *pgxpool.Pool) *PostgresInventoryRepository {
-	return &PostgresInventoryRepository{DB: dbpool}
+func NewInventoryRepository(dbpool *pgxpool.Pool, reservationTTL time.Duration) *PostgresInventoryRepository {
+	return &PostgresInventoryRepository{DB: dbpool, ReservationTTL: reservationTTL}
 }
 
 func (r *PostgresInventoryRepository) DecrementStock(ctx context.Context, productId uuid.UUID, quantity int) error {
+	return decrementStock(ctx, r.DB, productId, quantity)
+}
+
+func (r *PostgresInventoryRepository) IncrementStock(ctx context.Context, productId uuid.UUID, quantity int) error {
+	return incrementStock(ctx, r.DB, productId, quantity)
+}
+
+// CommitReservation converte a reserva do pedido pago em baixa definitiva de
+// estoque. Uma reserva que venceu antes do pagamento só é efetivada se o saldo
+// dela ainda estiver livre; se outro pedido já o reservou, as reservas são
+// liberadas e um StockRejected vai para o outbox na mesma transação, para que
+// a saga estorne o pedido pago. Retorna StockOutcomeDuplicate se não havia
+// nada a efetivar (reentrega ou pedido sem reserva).
+func (r *PostgresInventoryRepository) CommitReservation(ctx context.Context, orderID, customerID uuid.UUID) (StockOutcome, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	reservations, err := lockReservations(ctx, tx, orderID, ReservationActive, ReservationExpired)
+	if err != nil {
+		return "", err
+	}
+
+	if len(reservations) == 0 {
+		return StockOutcomeDuplicate, nil
+	}
+
+	// Reservas vencidas não entram na soma de reservas ativas, então o saldo
+	// delas é conferido de novo, como numa reserva nova.
+	expired := make(map[uuid.UUID]int)
+	var ids []uuid.UUID
+	for _, reservation := range reservations {
+		if reservation.status == ReservationExpired {
+			ids = append(ids, reservation.productID)
+			expired[reservation.productID] = reservation.quantity
+		}
+	}
+
+	outcome := StockOutcomeCommitted
+	if len(ids) > 0 {
+		err := checkAvailability(ctx, tx, orderID, ids, expired)
+		var stockErr *InsufficientStockError
+		switch {
+		case errors.As(err, &stockErr):
+			outcome = StockOutcomeRejected
+			if err := setReservationStatus(ctx, tx, orderID, ReservationReleased, ReservationActive, ReservationExpired); err != nil {
+				return "", err
+			}
+
+			rejected := events.StockRejectedEvent{
+				Version:    events.StockEventVersion,
+				OrderID:    orderID,
+				CustomerID: customerID,
+				Reason:     stockErr.Error(),
+				Shortages:  stockErr.Shortages,
+				OccurredAt: time.Now().UTC(),
+			}
+			if err := enqueueOutbox(ctx, tx, orderID, events.StockRejectedType, model.OutboxDestinationKafka, events.InventoryTopic, rejected); err != nil {
+				return "", err
+			}
+		case err != nil:
+			return "", err
+		}
+	}
+
+	if outcome == StockOutcomeCommitted {
+		for _, reservation := range reservations {
+			if err := decrementStock(ctx, tx, reservation.productID, reservation.quantity); err != nil {
+				return "", fmt.Errorf("erro ao baixar estoque do produto %s: %w", reservation.productID, err)
+			}
+		}
+
+		if err := setReservationStatus(ctx, tx, orderID, ReservationCommitted, ReservationActive, ReservationExpired); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("erro ao comitar transação: %w", err)
+	}
+
+	return outcome, nil
+}
+
+// RestockOrder devolve ao estoque os itens de um pedido cancelado, reembolsado
+// ou excluído: reservas ainda ativas são liberadas e reservas já efetivadas
+// voltam ao saldo físico. A devolução é registrada em stock_restocks na mesma
+// transação, então cada pedido é reposto no máximo uma vez; o retorno indica
+// se algo foi devolvido agora.
+func (r *PostgresInventoryRepository) RestockOrder(ctx context.Context, orderID uuid.UUID, reason string) (bool, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `INSERT INTO stock_restocks (order_id, reason) VALUES ($1, $2) ON CONFLICT (order_id) DO NOTHING`, orderID, reason)
+	if err != nil {
+		return false, fmt.Errorf("erro ao registrar reposição do pedido: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	reservations, err := lockReservations(ctx, tx, orderID, ReservationActive, ReservationCommitted)
+	if err != nil {
+		return false, err
+	}
+
+	for _, reservation := range reservations {
+		if reservation.status != ReservationCommitted {
+			continue
+		}
+		if err := incrementStock(ctx, tx, reservation.productID, reservation.quantity); err != nil {
+			return false, fmt.Errorf("erro ao repor o produto %s: %w", reservation.productID, err)
+		}
+	}
+
+	if err := setReservationStatus(ctx, tx, orderID, ReservationReleased, ReservationActive, ReservationCommitted); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("erro ao comitar transação: %w", err)
+	}
+
+	return len(reservations) > 0, nil
+}
+
+// ExpireReservations marca como vencidas as reservas ativas cujo prazo passou,
+// até limit pedidos por chamada, e grava um StockExpired por pedido no outbox
+// na mesma transação. Devolve quantos pedidos tiveram a reserva vencida.
+func (r *PostgresInventoryRepository) ExpireReservations(ctx context.Context, limit int) (int, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// O filtro status = 'active' no UPDATE é reavaliado após a espera por
+	// bloqueio, então dois sweepers concorrentes nunca vencem o mesmo pedido.
+	query := `
+		UPDATE stock_reservations SET status = 'expired', updated_at = $2
+		WHERE status = 'active' AND order_id IN (
+			SELECT DISTINCT order_id FROM stock_reservations
+			WHERE status = 'active' AND expires_at <= $2
+			LIMIT $1
+		)
+		RETURNING order_id, expires_at
+	`
+
+	now := time.Now().UTC()
+	rows, err := tx.Query(ctx, query, limit, now)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao vencer reservas de estoque: %w", err)
+	}
+
+	expired := make(map[uuid.UUID]time.Time)
+	for rows.Next() {
+		var orderID uuid.UUID
+		var expiresAt time.Time
+		if err := rows.Scan(&orderID, &expiresAt); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("erro ao ler reserva vencida: %w", err)
+		}
+		expired[orderID] = expiresAt
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("erro ao vencer reservas de estoque: %w", err)
+	}
+
+	for orderID, expiresAt := range expired {
+		event := events.StockExpiredEvent{
+			Version:    events.StockEventVersion,
+			OrderID:    orderID,
+			ExpiredAt:  expiresAt,
+			OccurredAt: now,
+		}
+		if err := enqueueOutbox(ctx, tx, orderID, events.StockExpiredType, model.OutboxDestinationKafka, events.InventoryTopic, event); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("erro ao comitar transação: %w", err)
+	}
+
+	return len(expired), nil
+}
+
+// ReserveItems reserva todos os itens do pedido numa única transação: ou todos
+// são reservados, ou nenhum. A reserva segura o estoque por ReservationTTL sem
+// baixar stock_quantity; a baixa só acontece em CommitReservation. Em caso de
+// falta, o erro é um *InsufficientStockError com cada produto que faltou.
+func (r *PostgresInventoryRepository) ReserveItems(ctx context.Context, orderID uuid.UUID, items []events.OrderItemCreated) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := reserveItems(ctx, tx, orderID, items, time.Now().UTC().Add(r.ReservationTTL)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("erro ao comitar transação: %w", err)
+	}
+
+	return nil
+}
+
+// ProcessOrderCreated reserva o estoque do pedido junto com o registro em
+// processed_events e o evento StockReserved no outbox. Se faltar estoque, nada
+// é baixado e o pedido é registrado como rejeitado, com um StockRejected no
+// outbox. Uma reentrega do mesmo evento encontra o registro e retorna
+// StockOutcomeDuplicate sem tocar no estoque.
+func (r *PostgresInventoryRepository) ProcessOrderCreated(ctx context.Context, event events.OrderCreatedEvent) (StockOutcome, error) {
+	err := r.reserve(ctx, event)
+	if err == nil {
+		return StockOutcomeReserved, nil
+	}
+
+	if errors.Is(err, errAlreadyProcessed) {
+		return StockOutcomeDuplicate, nil
+	}
+
+	var stockErr *InsufficientStockError
+	if !errors.As(err, &stockErr) {
+		return "", err
+	}
+
+	if err := r.reject(ctx, event, stockErr); err != nil {
+		if errors.Is(err, errAlreadyProcessed) {
+			return StockOutcomeDuplicate, nil
+		}
+		return "", err
+	}
+
+	return StockOutcomeRejected, nil
+}
+
+var errAlreadyProcessed = errors.New("evento já processado")
+
+func (r *PostgresInventoryRepository) reserve(ctx context.Context, event events.OrderCreatedEvent) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	first, err := markEventProcessed(ctx, tx, inventoryConsumer, events.OrderCreatedType, event.OrderID, string(StockOutcomeReserved))
+	if err != nil {
+		return err
+	}
+
+	if !first {
+		return errAlreadyProcessed
+	}
+
+	expiresAt := time.Now().UTC().Add(r.ReservationTTL)
+	if err := reserveItems(ctx, tx, event.OrderID, event.Items, expiresAt); err != nil {
+		return err
+	}
+
+	reserved := events.StockReservedEvent{
+		Version:    events.StockEventVersion,
+		OrderID:    event.OrderID,
+		CustomerID: event.CustomerID,
+		ExpiresAt:  expiresAt,
+		OccurredAt: time.Now().UTC(),
+	}
+	if err := enqueueOutbox(ctx, tx, event.OrderID, events.StockReservedType, model.OutboxDestinationKafka, events.InventoryTopic, reserved); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("erro ao comitar transação: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresInventoryRepository) reject(ctx context.Context, event events.OrderCreatedEvent, cause *InsufficientStockError) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	first, err := markEventProcessed(ctx, tx, inventoryConsumer, events.OrderCreatedType, event.OrderID, string(StockOutcomeRejected))
+	if err != nil {
+		return err
+	}
+
+	if !first {
+		return errAlreadyProcessed
+	}
+
+	rejected := events.StockRejectedEvent{
+		Version:    events.StockEventVersion,
+		OrderID:    event.OrderID,
+		CustomerID: event.CustomerID,
+		Reason:     cause.Error(),
+		Shortages:  cause.Shortages,
+		OccurredAt: time.Now().UTC(),
+	}
+	if err := enqueueOutbox(ctx, tx, event.OrderID, events.StockRejectedType, model.OutboxDestinationKafka, events.InventoryTopic, rejected); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("erro ao comitar transação: %w", err)
+	}
+
+	return nil
+}
+
+// reserveItems confere o saldo de todos os itens com checkAvailability e só
+// então grava as reservas.
+func reserveItems(ctx context.Context, tx pgx.Tx, orderID uuid.UUID, items []events.OrderItemCreated, expiresAt time.Time) error {
+	requested := make(map[uuid.UUID]int, len(items))
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range sortedByProduct(items) {
+		if _, ok := requested[item.ProductID]; !ok {
+			ids = append(ids, item.ProductID)
+		}
+		requested[item.ProductID] += item.Quantity
+	}
+
+	if err := checkAvailability(ctx, tx, orderID, ids, requested); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		_, err := tx.Exec(ctx, `INSERT INTO stock_reservations (order_id, product_id, quantity, expires_at) VALUES ($1, $2, $3, $4)`, orderID, id, requested[id], expiresAt)
+		if err != nil {
+			return fmt.Errorf("erro ao reservar o produto %s: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// checkAvailability bloqueia as linhas de products em ordem de ID, para que
+// reservas concorrentes nunca se bloqueiem em ordens opostas, e confere o saldo
+// disponível (estoque menos reservas ativas) de todos os produtos pedidos. Em
+// caso de falta, o erro é um *InsufficientStockError com cada produto que faltou.
+func checkAvailability(ctx context.Context, tx pgx.Tx, orderID uuid.UUID, ids []uuid.UUID, requested map[uuid.UUID]int) error {
+	query := `
+		SELECT id, sku, stock_quantity FROM products
+		WHERE id = ANY($1)
+		ORDER BY id
+		FOR UPDATE
+	`
+
+	rows, err := tx.Query(ctx, query, ids)
+	if err != nil {
+		return fmt.Errorf("erro ao bloquear produtos: %w", err)
+	}
+
+	type productStock struct {
+		sku       string
+		available int
+	}
+	found := make(map[uuid.UUID]*productStock, len(ids))
+	for rows.Next() {
+		var id uuid.UUID
+		var product productStock
+		if err := rows.Scan(&id, &product.sku, &product.available); err != nil {
+			rows.Close()
+			return fmt.Errorf("erro ao ler produto: %w", err)
+		}
+		found[id] = &product
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("erro ao bloquear produtos: %w", err)
+	}
+
+	// As reservas são somadas num comando separado, depois dos bloqueios: em
+	// READ COMMITTED ele enxerga as reservas de quem segurava os produtos antes.
+	held, err := activeReservations(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+
+	var shortages []events.StockShortage
+	for _, id := range ids {
+		product, ok := found[id]
+		if !ok {
+			shortages = append(shortages, events.StockShortage{ProductID: id, Requested: requested[id]})
+			continue
+		}
+		product.available -= held[id]
+		if product.available < requested[id] {
+			shortages = append(shortages, events.StockShortage{ProductID: id, SKU: product.sku, Requested: requested[id], Available: max(product.available, 0)})
+		}
+	}
+
+	if len(shortages) > 0 {
+		return &InsufficientStockError{OrderID: orderID, Shortages: shortages}
+	}
+
+	return nil
+}
+
+func activeReservations(ctx context.Context, tx pgx.Tx, productIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	query := `
+		SELECT product_id, SUM(quantity) FROM stock_reservations
+		WHERE status = 'active' AND product_id = ANY($1)
+		GROUP BY product_id
+	`
+
+	rows, err := tx.Query(ctx, query, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao somar reservas ativas: %w", err)
+	}
+	defer rows.Close()
+
+	held := make(map[uuid.UUID]int, len(productIDs))
+	for rows.Next() {
+		var productID uuid.UUID
+		var quantity int
+		if err := rows.Scan(&productID, &quantity); err != nil {
+			return nil, fmt.Errorf("erro ao ler reservas ativas: %w", err)
+		}
+		held[productID] = quantity
+	}
+
+	return held, rows.Err()
+}
+
+type reservation struct {
+	productID uuid.UUID
+	quantity  int
+	status    ReservationStatus
+}
+
+// lockReservations bloqueia as reservas do pedido nos status informados, em
+// ordem de produto.
+func lockReservations(ctx context.Context, tx pgx.Tx, orderID uuid.UUID, statuses ...ReservationStatus) ([]reservation, error) {
+	query := `
+		SELECT product_id, quantity, status FROM stock_reservations
+		WHERE order_id = $1 AND status = ANY($2)
+		ORDER BY product_id
+		FOR UPDATE
+	`
+
+	rows, err := tx.Query(ctx, query, orderID, statusNames(statuses))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar reservas do pedido: %w", err)
+	}
+	defer rows.Close()
+
+	var reservations []reservation
+	for rows.Next() {
+		var res reservation
+		if err := rows.Scan(&res.productID, &res.quantity, &res.status); err != nil {
+			return nil, fmt.Errorf("erro ao ler reserva do pedido: %w", err)
+		}
+		reservations = append(reservations, res)
+	}
+
+	return reservations, rows.Err()
+}
+
+func setReservationStatus(ctx context.Context, tx pgx.Tx, orderID uuid.UUID, status ReservationStatus, from ...ReservationStatus) error {
+	query := `
+		UPDATE stock_reservations SET status = $1, updated_at = $2 WHERE order_id = $3 AND status = ANY($4)
+	`
+
+	if _, err := tx.Exec(ctx, query, string(status), time.Now().UTC(), orderID, statusNames(from)); err != nil {
+		return fmt.Errorf("erro ao atualizar reservas do pedido: %w", err)
+	}
+
+	return nil
+}
+
+func statusNames(statuses []ReservationStatus) []string {
+	names := make([]string, len(statuses))
+	for i, status := range statuses {
+		names[i] = string(status)
+	}
+	return names
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+func decrementStock(ctx context.Context, db execer, productId uuid.UUID, quantity int) error {
 	query := `
 		UPDATE products SET stock_quantity = stock_quantity - $1, updated_at = $2 WHERE id = $3 
 	`
 
-	tag, err := r.DB.Exec(ctx, query, quantity, time.Now(), productId)
+	tag, err := db.Exec(ctx, query, quantity, time.Now(), productId)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == checkViolation {
+			return ErrInsufficientStock
+		}
+		return fmt.Errorf("erro ao atualizar a tabela products: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+func incrementStock(ctx context.Context, db execer, productId uuid.UUID, quantity int) error {
+	query := `
+		UPDATE products SET stock_quantity = stock_quantity + $1, updated_at = $2 WHERE id = $3
+	`
+
+	tag, err := db.Exec(ctx, query, quantity, time.Now(), productId)
 	if err != nil {
 		return fmt.Errorf("erro ao atualizar a tabela products: %w", err)
 	}
@@ -38,3 +617,13 @@ func (r *PostgresInventoryRepository) DecrementStock(ctx context.Context, produc
 
 	return nil
 }
+
+// sortedByProduct ordena os itens por produto para que transações concorrentes
+// bloqueiem as linhas de products sempre na mesma ordem.
+func sortedByProduct(items []events.OrderItemCreated) []events.OrderItemCreated {
+	sorted := slices.Clone(items)
+	slices.SortFunc(sorted, func(a, b events.OrderItemCreated) int {
+		return slices.Compare(a.ProductID[:], b.ProductID[:])
+	})
+	return sorted
+}
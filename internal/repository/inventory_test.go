@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mlucas4330/orderflow-pro/internal/events"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestProduct(t *testing.T, dbpool *pgxpool.Pool, stock int) uuid.UUID {
+	productID := uuid.New()
+	_, err := dbpool.Exec(context.Background(),
+		"INSERT INTO products (id, name, sku, price, stock_quantity) VALUES ($1, $2, $3, $4, $5)",
+		productID, "Produto de teste", "TEST-"+productID.String(), 10, stock,
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_, err := dbpool.Exec(context.Background(), "DELETE FROM stock_reservations WHERE product_id = $1", productID)
+		require.NoError(t, err)
+		_, err = dbpool.Exec(context.Background(), "DELETE FROM products WHERE id = $1", productID)
+		require.NoError(t, err)
+	})
+
+	return productID
+}
+
+func stockOf(t *testing.T, dbpool *pgxpool.Pool, productID uuid.UUID) int {
+	var stock int
+	err := dbpool.QueryRow(context.Background(), "SELECT stock_quantity FROM products WHERE id = $1", productID).Scan(&stock)
+	require.NoError(t, err)
+	return stock
+}
+
+func reservedOf(t *testing.T, dbpool *pgxpool.Pool, productID uuid.UUID) int {
+	var reserved int
+	err := dbpool.QueryRow(context.Background(), "SELECT COALESCE(SUM(quantity), 0) FROM stock_reservations WHERE product_id = $1 AND status = 'active'", productID).Scan(&reserved)
+	require.NoError(t, err)
+	return reserved
+}
+
+func setupInventoryTest(t *testing.T, reservationTTL time.Duration) (*PostgresInventoryRepository, *pgxpool.Pool) {
+	_, dbpool, redisClient := setupTest(t)
+	t.Cleanup(func() {
+		_, err := dbpool.Exec(context.Background(), "TRUNCATE TABLE processed_events, stock_restocks, outbox")
+		require.NoError(t, err)
+		dbpool.Close()
+		redisClient.Close()
+	})
+
+	return NewInventoryRepository(dbpool, reservationTTL), dbpool
+}
+
+func TestRestockOrderIsIdempotent(t *testing.T) {
+	repo, dbpool := setupInventoryTest(t, time.Hour)
+	ctx := context.Background()
+
+	productID := createTestProduct(t, dbpool, 10)
+	orderID := uuid.New()
+	items := []events.OrderItemCreated{{ProductID: productID, Quantity: 3}}
+
+	outcome, err := repo.ProcessOrderCreated(ctx, events.OrderCreatedEvent{OrderID: orderID, Items: items})
+	require.NoError(t, err)
+	require.Equal(t, StockOutcomeReserved, outcome)
+
+	outcome, err = repo.CommitReservation(ctx, orderID, uuid.New())
+	require.NoError(t, err)
+	require.Equal(t, StockOutcomeCommitted, outcome)
+	require.Equal(t, 7, stockOf(t, dbpool, productID))
+
+	applied, err := repo.RestockOrder(ctx, orderID, events.OrderCancelledType)
+	require.NoError(t, err)
+	require.True(t, applied)
+	require.Equal(t, 10, stockOf(t, dbpool, productID))
+
+	applied, err = repo.RestockOrder(ctx, orderID, events.OrderDeletedType)
+	require.NoError(t, err)
+	require.False(t, applied, "A reposição repetida do mesmo pedido deveria ser ignorada")
+	require.Equal(t, 10, stockOf(t, dbpool, productID))
+}
+
+func TestProcessOrderCreatedIsIdempotent(t *testing.T) {
+	repo, dbpool := setupInventoryTest(t, time.Hour)
+	ctx := context.Background()
+
+	productID := createTestProduct(t, dbpool, 10)
+	event := events.OrderCreatedEvent{
+		OrderID: uuid.New(),
+		Items:   []events.OrderItemCreated{{ProductID: productID, Quantity: 4}},
+	}
+
+	outcome, err := repo.ProcessOrderCreated(ctx, event)
+	require.NoError(t, err)
+	require.Equal(t, StockOutcomeReserved, outcome)
+
+	outcome, err = repo.ProcessOrderCreated(ctx, event)
+	require.NoError(t, err)
+	require.Equal(t, StockOutcomeDuplicate, outcome, "A reentrega do evento não deveria reservar o estoque de novo")
+
+	require.Equal(t, 4, reservedOf(t, dbpool, productID))
+	require.Equal(t, 10, stockOf(t, dbpool, productID), "A reserva não deveria baixar o estoque físico")
+}
+
+func TestProcessOrderCreatedRejectsInsufficientStock(t *testing.T) {
+	repo, dbpool := setupInventoryTest(t, time.Hour)
+	ctx := context.Background()
+
+	available := createTestProduct(t, dbpool, 10)
+	scarce := createTestProduct(t, dbpool, 1)
+	event := events.OrderCreatedEvent{
+		OrderID: uuid.New(),
+		Items: []events.OrderItemCreated{
+			{ProductID: available, Quantity: 2},
+			{ProductID: scarce, Quantity: 5},
+		},
+	}
+
+	outcome, err := repo.ProcessOrderCreated(ctx, event)
+	require.NoError(t, err)
+	require.Equal(t, StockOutcomeRejected, outcome)
+	require.Equal(t, 0, reservedOf(t, dbpool, available), "Nenhum item deveria ser reservado quando a reserva é rejeitada")
+	require.Equal(t, 0, reservedOf(t, dbpool, scarce))
+
+	var eventType string
+	err = dbpool.QueryRow(ctx, "SELECT event_type FROM outbox WHERE aggregate_id = $1", event.OrderID).Scan(&eventType)
+	require.NoError(t, err)
+	require.Equal(t, events.StockRejectedType, eventType)
+
+	applied, err := repo.RestockOrder(ctx, event.OrderID, events.OrderCancelledType)
+	require.NoError(t, err)
+	require.False(t, applied, "Pedido rejeitado não deveria devolver estoque")
+	require.Equal(t, 10, stockOf(t, dbpool, available))
+}
+
+func TestReserveItemsIsAllOrNothing(t *testing.T) {
+	repo, dbpool := setupInventoryTest(t, time.Hour)
+	ctx := context.Background()
+
+	first := createTestProduct(t, dbpool, 10)
+	second := createTestProduct(t, dbpool, 2)
+	missing := uuid.New()
+	orderID := uuid.New()
+
+	err := repo.ReserveItems(ctx, orderID, []events.OrderItemCreated{
+		{ProductID: first, Quantity: 4},
+		{ProductID: second, Quantity: 2},
+		{ProductID: second, Quantity: 1},
+		{ProductID: missing, Quantity: 1},
+	})
+	var stockErr *InsufficientStockError
+	require.ErrorAs(t, err, &stockErr)
+	require.ErrorIs(t, err, ErrInsufficientStock)
+	require.ElementsMatch(t, []events.StockShortage{
+		{ProductID: second, SKU: "TEST-" + second.String(), Requested: 3, Available: 2},
+		{ProductID: missing, Requested: 1},
+	}, stockErr.Shortages)
+	require.Equal(t, 0, reservedOf(t, dbpool, first), "Nenhum item deveria ser reservado quando falta estoque")
+
+	err = repo.ReserveItems(ctx, orderID, []events.OrderItemCreated{
+		{ProductID: second, Quantity: 2},
+		{ProductID: first, Quantity: 4},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 4, reservedOf(t, dbpool, first))
+	require.Equal(t, 2, reservedOf(t, dbpool, second))
+
+	err = repo.ReserveItems(ctx, uuid.New(), []events.OrderItemCreated{{ProductID: second, Quantity: 1}})
+	require.ErrorAs(t, err, &stockErr, "Estoque reservado por outro pedido não deveria estar disponível")
+	require.Equal(t, 0, stockErr.Shortages[0].Available)
+}
+
+func TestExpireReservations(t *testing.T) {
+	repo, dbpool := setupInventoryTest(t, -time.Minute)
+	ctx := context.Background()
+
+	productID := createTestProduct(t, dbpool, 5)
+	orderID := uuid.New()
+	items := []events.OrderItemCreated{{ProductID: productID, Quantity: 5}}
+
+	outcome, err := repo.ProcessOrderCreated(ctx, events.OrderCreatedEvent{OrderID: orderID, Items: items})
+	require.NoError(t, err)
+	require.Equal(t, StockOutcomeReserved, outcome)
+
+	expired, err := repo.ExpireReservations(ctx, 100)
+	require.NoError(t, err)
+	require.Equal(t, 1, expired)
+	require.Equal(t, 0, reservedOf(t, dbpool, productID), "Reserva vencida deveria liberar o estoque")
+
+	expired, err = repo.ExpireReservations(ctx, 100)
+	require.NoError(t, err)
+	require.Zero(t, expired, "Reserva já vencida não deveria gerar outro evento")
+
+	var count int
+	err = dbpool.QueryRow(ctx, "SELECT count(*) FROM outbox WHERE aggregate_id = $1 AND event_type = $2", orderID, events.StockExpiredType).Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+
+	// Sem concorrência, o pagamento que chega depois do vencimento ainda
+	// efetiva a reserva.
+	outcome, err = repo.CommitReservation(ctx, orderID, uuid.New())
+	require.NoError(t, err)
+	require.Equal(t, StockOutcomeCommitted, outcome)
+	require.Equal(t, 0, stockOf(t, dbpool, productID))
+}
+
+func TestCommitReservationRejectsExpiredReservationTakenByAnotherOrder(t *testing.T) {
+	repo, dbpool := setupInventoryTest(t, -time.Minute)
+	ctx := context.Background()
+
+	productID := createTestProduct(t, dbpool, 5)
+	orderID := uuid.New()
+	items := []events.OrderItemCreated{{ProductID: productID, Quantity: 5}}
+
+	outcome, err := repo.ProcessOrderCreated(ctx, events.OrderCreatedEvent{OrderID: orderID, Items: items})
+	require.NoError(t, err)
+	require.Equal(t, StockOutcomeReserved, outcome)
+
+	expired, err := repo.ExpireReservations(ctx, 100)
+	require.NoError(t, err)
+	require.Equal(t, 1, expired)
+
+	// O saldo liberado pelo vencimento foi para outro pedido; o pagamento que
+	// chega depois não pode baixá-lo de novo.
+	outcome, err = repo.ProcessOrderCreated(ctx, events.OrderCreatedEvent{OrderID: uuid.New(), Items: items})
+	require.NoError(t, err)
+	require.Equal(t, StockOutcomeReserved, outcome)
+
+	outcome, err = repo.CommitReservation(ctx, orderID, uuid.New())
+	require.NoError(t, err)
+	require.Equal(t, StockOutcomeRejected, outcome)
+	require.Equal(t, 5, stockOf(t, dbpool, productID), "Reserva vencida não deveria baixar o estoque")
+
+	var count int
+	err = dbpool.QueryRow(ctx, "SELECT count(*) FROM outbox WHERE aggregate_id = $1 AND event_type = $2", orderID, events.StockRejectedType).Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 1, count, "O pedido pago deveria ser estornado pela saga")
+
+	outcome, err = repo.CommitReservation(ctx, orderID, uuid.New())
+	require.NoError(t, err)
+	require.Equal(t, StockOutcomeDuplicate, outcome, "A reentrega do pagamento não deveria gerar outro StockRejected")
+}
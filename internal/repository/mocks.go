@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mlucas4330/orderflow-pro/internal/events"
@@ -28,21 +29,41 @@ func (m *MockOrderRepository) FindOrderById(ctx context.Context, id uuid.UUID) (
 	return args.Get(0).(*model.Order), args.Error(1)
 }
 
-func (m *MockOrderRepository) FindOrders(ctx context.Context) ([]model.Order, error) {
-	args := m.Called(ctx)
+func (m *MockOrderRepository) FindOrders(ctx context.Context, filter OrderFilter) (*OrderPage, error) {
+	args := m.Called(ctx, filter)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]model.Order), args.Error(1)
+	return args.Get(0).(*OrderPage), args.Error(1)
 }
 
-func (m *MockOrderRepository) UpdateOrder(ctx context.Context, id uuid.UUID, status model.Status) error {
-	args := m.Called(ctx, id, status)
-	return args.Error(0)
+func (m *MockOrderRepository) UpdateOrder(ctx context.Context, id uuid.UUID, status model.Status, actor uuid.UUID) (model.Status, error) {
+	args := m.Called(ctx, id, status, actor)
+	return args.Get(0).(model.Status), args.Error(1)
+}
+
+func (m *MockOrderRepository) ConfirmOrder(ctx context.Context, id uuid.UUID) (model.Status, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.Status), args.Error(1)
+}
+
+func (m *MockOrderRepository) CancelOrder(ctx context.Context, id uuid.UUID, reason string, actor uuid.UUID) (model.Status, error) {
+	args := m.Called(ctx, id, reason, actor)
+	return args.Get(0).(model.Status), args.Error(1)
+}
+
+func (m *MockOrderRepository) ExpireOrder(ctx context.Context, id uuid.UUID, reason string) (model.Status, error) {
+	args := m.Called(ctx, id, reason)
+	return args.Get(0).(model.Status), args.Error(1)
 }
 
-func (m *MockOrderRepository) DeleteOrder(ctx context.Context, orderID uuid.UUID) error {
-	args := m.Called(ctx, orderID)
+func (m *MockOrderRepository) RefundOrder(ctx context.Context, id uuid.UUID, reason string) (model.Status, error) {
+	args := m.Called(ctx, id, reason)
+	return args.Get(0).(model.Status), args.Error(1)
+}
+
+func (m *MockOrderRepository) DeleteOrder(ctx context.Context, orderID uuid.UUID, actor uuid.UUID) error {
+	args := m.Called(ctx, orderID, actor)
 	return args.Error(0)
 }
 
@@ -50,17 +71,29 @@ type MockIdempotencyRepository struct {
 	mock.Mock
 }
 
-func (m *MockIdempotencyRepository) GetResponse(ctx context.Context, key, userID uuid.UUID) (*model.IdempotencyResponse, error) {
-	args := m.Called(ctx, key, userID)
+func (m *MockIdempotencyRepository) ClaimKey(ctx context.Context, key, userID uuid.UUID, requestHash string, lease time.Duration) (*model.IdempotencyResponse, error) {
+	args := m.Called(ctx, key, userID, requestHash, lease)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.IdempotencyResponse), args.Error(1)
 }
-func (m *MockIdempotencyRepository) SaveResponse(ctx context.Context, key, userID uuid.UUID, response *model.IdempotencyResponse) error {
-	args := m.Called(ctx, key, userID, response)
+func (m *MockIdempotencyRepository) SaveResponse(ctx context.Context, key, userID uuid.UUID, response *model.IdempotencyResponse, ttl time.Duration) error {
+	args := m.Called(ctx, key, userID, response, ttl)
 	return args.Error(0)
 }
+func (m *MockIdempotencyRepository) RenewLease(ctx context.Context, key, userID uuid.UUID, lease time.Duration) error {
+	args := m.Called(ctx, key, userID, lease)
+	return args.Error(0)
+}
+func (m *MockIdempotencyRepository) ReleaseKey(ctx context.Context, key, userID uuid.UUID) error {
+	args := m.Called(ctx, key, userID)
+	return args.Error(0)
+}
+func (m *MockIdempotencyRepository) PurgeExpired(ctx context.Context, limit int) (int, error) {
+	args := m.Called(ctx, limit)
+	return args.Int(0), args.Error(1)
+}
 
 type MockProductServiceClient struct {
 	mock.Mock
@@ -74,6 +107,78 @@ func (m *MockProductServiceClient) GetProductDetails(ctx context.Context, req *p
 	return args.Get(0).(*pb.GetProductDetailsResponse), args.Error(1)
 }
 
+func (m *MockProductServiceClient) BatchGetProductDetails(ctx context.Context, req *pb.BatchGetProductDetailsRequest, opts ...grpc.CallOption) (*pb.BatchGetProductDetailsResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.BatchGetProductDetailsResponse), args.Error(1)
+}
+
+func (m *MockProductServiceClient) CreateProduct(ctx context.Context, req *pb.CreateProductRequest, opts ...grpc.CallOption) (*pb.Product, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.Product), args.Error(1)
+}
+
+func (m *MockProductServiceClient) UpdateProduct(ctx context.Context, req *pb.UpdateProductRequest, opts ...grpc.CallOption) (*pb.Product, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.Product), args.Error(1)
+}
+
+func (m *MockProductServiceClient) DeactivateProduct(ctx context.Context, req *pb.DeactivateProductRequest, opts ...grpc.CallOption) (*pb.Product, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.Product), args.Error(1)
+}
+
+func (m *MockProductServiceClient) ListProducts(ctx context.Context, req *pb.ListProductsRequest, opts ...grpc.CallOption) (*pb.ListProductsResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.ListProductsResponse), args.Error(1)
+}
+
+func (m *MockProductServiceClient) GetProductBySku(ctx context.Context, req *pb.GetProductBySkuRequest, opts ...grpc.CallOption) (*pb.Product, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.Product), args.Error(1)
+}
+
+func (m *MockProductServiceClient) GetPriceAt(ctx context.Context, req *pb.GetPriceAtRequest, opts ...grpc.CallOption) (*pb.PriceEntry, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.PriceEntry), args.Error(1)
+}
+
+func (m *MockProductServiceClient) SchedulePriceChange(ctx context.Context, req *pb.SchedulePriceChangeRequest, opts ...grpc.CallOption) (*pb.PriceEntry, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.PriceEntry), args.Error(1)
+}
+
+func (m *MockProductServiceClient) ListPriceHistory(ctx context.Context, req *pb.ListPriceHistoryRequest, opts ...grpc.CallOption) (*pb.ListPriceHistoryResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.ListPriceHistoryResponse), args.Error(1)
+}
+
 type MockKafkaProducer struct {
 	mock.Mock
 }
@@ -83,6 +188,41 @@ func (m *MockKafkaProducer) PublishOrderCreated(ctx context.Context, event event
 	return args.Error(0)
 }
 
+func (m *MockKafkaProducer) PublishOrderStatusChanged(ctx context.Context, event events.OrderStatusChangedEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockKafkaProducer) PublishOrderCancelled(ctx context.Context, event events.OrderCancelledEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockKafkaProducer) PublishOrderRefunded(ctx context.Context, event events.OrderRefundedEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockKafkaProducer) PublishOrderDeleted(ctx context.Context, event events.OrderDeletedEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockKafkaProducer) PublishStockReserved(ctx context.Context, event events.StockReservedEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockKafkaProducer) PublishStockRejected(ctx context.Context, event events.StockRejectedEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockKafkaProducer) PublishStockExpired(ctx context.Context, event events.StockExpiredEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
 func (m *MockKafkaProducer) Close() error {
 	return nil
 }
@@ -98,3 +238,152 @@ func (m *MockRabbitMQProducer) Publish(ctx context.Context, queueName string, bo
 
 func (m *MockRabbitMQProducer) Close() {
 }
+
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepository) ClaimPending(ctx context.Context, topics []string, limit int, lease time.Duration) ([]model.OutboxMessage, error) {
+	args := m.Called(ctx, topics, limit, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.OutboxMessage), args.Error(1)
+}
+
+func (m *MockOutboxRepository) MarkPublished(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) MarkFailed(ctx context.Context, id int64, cause error, retryAt time.Time) error {
+	args := m.Called(ctx, id, cause, retryAt)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) MarkParked(ctx context.Context, id int64, cause error) error {
+	args := m.Called(ctx, id, cause)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) OldestPendingCreatedAt(ctx context.Context, topics []string) (*time.Time, error) {
+	args := m.Called(ctx, topics)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+type MockInventoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockInventoryRepository) DecrementStock(ctx context.Context, productId uuid.UUID, quantity int) error {
+	args := m.Called(ctx, productId, quantity)
+	return args.Error(0)
+}
+
+func (m *MockInventoryRepository) IncrementStock(ctx context.Context, productId uuid.UUID, quantity int) error {
+	args := m.Called(ctx, productId, quantity)
+	return args.Error(0)
+}
+
+func (m *MockInventoryRepository) ReserveItems(ctx context.Context, orderID uuid.UUID, items []events.OrderItemCreated) error {
+	args := m.Called(ctx, orderID, items)
+	return args.Error(0)
+}
+
+func (m *MockInventoryRepository) CommitReservation(ctx context.Context, orderID, customerID uuid.UUID) (StockOutcome, error) {
+	args := m.Called(ctx, orderID, customerID)
+	return args.Get(0).(StockOutcome), args.Error(1)
+}
+
+func (m *MockInventoryRepository) RestockOrder(ctx context.Context, orderID uuid.UUID, reason string) (bool, error) {
+	args := m.Called(ctx, orderID, reason)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockInventoryRepository) ExpireReservations(ctx context.Context, limit int) (int, error) {
+	args := m.Called(ctx, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockInventoryRepository) ProcessOrderCreated(ctx context.Context, event events.OrderCreatedEvent) (StockOutcome, error) {
+	args := m.Called(ctx, event)
+	return args.Get(0).(StockOutcome), args.Error(1)
+}
+
+type MockProductRepository struct {
+	mock.Mock
+}
+
+func (m *MockProductRepository) FindProductById(ctx context.Context, id uuid.UUID) (*model.Product, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) FindProductsByIds(ctx context.Context, ids []uuid.UUID) ([]model.Product, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) FindProductBySku(ctx context.Context, sku string) (*model.Product, error) {
+	args := m.Called(ctx, sku)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) ListProducts(ctx context.Context, filter ProductFilter) ([]model.Product, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) CreateProduct(ctx context.Context, product *model.Product) error {
+	args := m.Called(ctx, product)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) UpdateProduct(ctx context.Context, id uuid.UUID, update ProductUpdate) (*model.Product, error) {
+	args := m.Called(ctx, id, update)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) FindPriceAt(ctx context.Context, productID uuid.UUID, at time.Time) (*model.ProductPrice, error) {
+	args := m.Called(ctx, productID, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ProductPrice), args.Error(1)
+}
+
+func (m *MockProductRepository) ListPrices(ctx context.Context, productID uuid.UUID) ([]model.ProductPrice, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.ProductPrice), args.Error(1)
+}
+
+func (m *MockProductRepository) SchedulePrice(ctx context.Context, price *model.ProductPrice) error {
+	args := m.Called(ctx, price)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) ApplyDuePrices(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
@@ -2,163 +2,64 @@ package repository
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 	pgx "github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mlucas4330/orderflow-pro/internal/cache"
 	"github.com/mlucas4330/orderflow-pro/internal/events"
-	"github.com/mlucas4330/orderflow-pro/internal/messaging/producer"
 	"github.com/mlucas4330/orderflow-pro/pkg/messaging"
 	"github.com/mlucas4330/orderflow-pro/pkg/model"
 	redis "github.com/redis/go-redis/v9"
 )
 
 type OrderRepository interface {
-	FindOrders(ctx context.Context) ([]model.Order, error)
+	FindOrders(ctx context.Context, filter OrderFilter) (*OrderPage, error)
 	FindOrderById(ctx context.Context, id uuid.UUID) (*model.Order, error)
 	CreateOrder(ctx context.Context, order *model.Order, orderItems []model.OrderItem) error
-	UpdateOrder(ctx context.Context, id uuid.UUID, status model.Status) error
-	DeleteOrder(ctx context.Context, id uuid.UUID) error
+	UpdateOrder(ctx context.Context, id uuid.UUID, status model.Status, actor uuid.UUID) (model.Status, error)
+	ConfirmOrder(ctx context.Context, id uuid.UUID) (model.Status, error)
+	CancelOrder(ctx context.Context, id uuid.UUID, reason string, actor uuid.UUID) (model.Status, error)
+	ExpireOrder(ctx context.Context, id uuid.UUID, reason string) (model.Status, error)
+	RefundOrder(ctx context.Context, id uuid.UUID, reason string) (model.Status, error)
+	DeleteOrder(ctx context.Context, id uuid.UUID, actor uuid.UUID) error
 }
 
+var ErrOrderStatusConflict = errors.New("o status do pedido foi alterado por outra requisição")
+
 type PostgresOrderRepository struct {
-	DB               *pgxpool.Pool
-	Redis            *redis.Client
-	KafkaProducer    producer.IKafkaProducer
-	RabbitMQProducer producer.IRabbitMQProducer
+	DB    *pgxpool.Pool
+	Redis redis.UniversalClient
+	Cache *cache.Cache
 }
 
-func NewOrderRepository(pgpool *pgxpool.Pool, redis *redis.Client, kafkaProducer producer.IKafkaProducer, rabbitProducer producer.IRabbitMQProducer) *PostgresOrderRepository {
+func NewOrderRepository(pgpool *pgxpool.Pool, redis redis.UniversalClient) *PostgresOrderRepository {
 	return &PostgresOrderRepository{
-		DB:               pgpool,
-		Redis:            redis,
-		KafkaProducer:    kafkaProducer,
-		RabbitMQProducer: rabbitProducer,
+		DB:    pgpool,
+		Redis: redis,
+		Cache: cache.New(redis),
 	}
 }
 
-func (r *PostgresOrderRepository) FindOrders(ctx context.Context) ([]model.Order, error) {
-	key := "orders:list:all"
-
-	result, err := r.Redis.Get(ctx, key).Result()
-
-	if err == nil {
-		var orders []model.Order
-		err := json.Unmarshal([]byte(result), &orders)
-		if err != nil {
-			return nil, fmt.Errorf("erro ao ler dados do json: %w", err)
-		}
-
-		return orders, nil
-	}
-
-	if err != redis.Nil {
-		log.Printf("Erro ao buscar do Redis, mas não é um cache miss: %v", err)
-	}
-
-	query := `SELECT id, customer_id, status, total, currency, created_at, updated_at FROM orders`
-	orderRows, err := r.DB.Query(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao buscar pedidos: %w", err)
-	}
-	defer orderRows.Close()
-
-	var orders []model.Order
-	for orderRows.Next() {
-		var order model.Order
-		err := orderRows.Scan(
-			&order.ID, &order.CustomerID, &order.Status, &order.Total,
-			&order.Currency, &order.CreatedAt, &order.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("erro ao escanear pedido: %w", err)
-		}
-		orders = append(orders, order)
-	}
-	if err = orderRows.Err(); err != nil {
-		return nil, fmt.Errorf("erro na iteração dos pedidos: %w", err)
-	}
-
-	if len(orders) == 0 {
-		return []model.Order{}, nil
-	}
-
-	orderIDs := make([]uuid.UUID, len(orders))
-	for i, order := range orders {
-		orderIDs[i] = order.ID
-	}
-
-	itemsQuery := `SELECT id, order_id, product_id, quantity, price_at_time FROM order_items WHERE order_id = ANY($1)`
-	itemRows, err := r.DB.Query(ctx, itemsQuery, orderIDs)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao buscar itens dos pedidos: %w", err)
-	}
-	defer itemRows.Close()
-
-	itemsByOrderID := make(map[uuid.UUID][]model.OrderItem)
-	for itemRows.Next() {
-		var orderItem model.OrderItem
-		if err := itemRows.Scan(&orderItem.ID, &orderItem.OrderID, &orderItem.ProductID, &orderItem.Quantity, &orderItem.PriceAtTime); err != nil {
-			return nil, fmt.Errorf("erro ao escanear item: %w", err)
-		}
-		itemsByOrderID[orderItem.OrderID] = append(itemsByOrderID[orderItem.OrderID], orderItem)
-	}
-	if err := itemRows.Err(); err != nil {
-		return nil, fmt.Errorf("erro na iteração dos itens: %w", err)
-	}
-
-	for i, order := range orders {
-		if orderItems, ok := itemsByOrderID[order.ID]; ok {
-			orders[i].OrderItems = orderItems
-		} else {
-			orders[i].OrderItems = []model.OrderItem{}
-		}
-	}
-
-	jsonData, err := json.Marshal(orders)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao transformar pedido em json: %w", err)
-	}
-
-	if err := r.Redis.Set(ctx, key, jsonData, 30*time.Second).Err(); err != nil {
-		log.Printf("Falha ao salvar pedidos no cache do Redis: %v", err)
-	}
-
-	return orders, nil
-}
-
 func (r *PostgresOrderRepository) FindOrderById(ctx context.Context, id uuid.UUID) (*model.Order, error) {
-	key := fmt.Sprintf("order:%s", id.String())
-
-	result, err := r.Redis.Get(ctx, key).Result()
-
-	if err == nil {
-		var order model.Order
-		err := json.Unmarshal([]byte(result), &order)
-		if err != nil {
-			return nil, fmt.Errorf("erro ao ler dados do json: %w", err)
-		}
-
-		return &order, nil
-	}
-
-	if err != redis.Nil {
-		log.Printf("Erro ao buscar do Redis, mas não é um cache miss: %v", err)
-	}
+	return cache.Fetch(ctx, r.Cache, orderCacheKey(id), orderCachePolicy, func(ctx context.Context) (*model.Order, error) {
+		return r.findOrderById(ctx, id)
+	})
+}
 
+func (r *PostgresOrderRepository) findOrderById(ctx context.Context, id uuid.UUID) (*model.Order, error) {
 	orderQuery := `
-		SELECT id, customer_id, status, total, currency, created_at, updated_at
+		SELECT id, customer_id, status, cancellation_reason, total, currency, created_at, updated_at
 		FROM orders
 		WHERE id = $1
 	`
 	var order model.Order
-	err = r.DB.QueryRow(ctx, orderQuery, id).Scan(
-		&order.ID, &order.CustomerID, &order.Status, &order.Total,
+	err := r.DB.QueryRow(ctx, orderQuery, id).Scan(
+		&order.ID, &order.CustomerID, &order.Status, &order.CancellationReason, &order.Total,
 		&order.Currency, &order.CreatedAt, &order.UpdatedAt,
 	)
 	if err != nil {
@@ -194,15 +95,6 @@ func (r *PostgresOrderRepository) FindOrderById(ctx context.Context, id uuid.UUI
 
 	order.OrderItems = orderItems
 
-	jsonData, err := json.Marshal(order)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao transformar pedido em json: %w", err)
-	}
-
-	if err := r.Redis.Set(ctx, key, jsonData, 10*time.Minute).Err(); err != nil {
-		log.Printf("Falha ao salvar pedido %s no cache do Redis: %v", id.String(), err)
-	}
-
 	return &order, nil
 }
 
@@ -211,9 +103,7 @@ func (r *PostgresOrderRepository) CreateOrder(ctx context.Context, order *model.
 	if err != nil {
 		return fmt.Errorf("erro ao iniciar transação: %w", err)
 	}
-	if err := defer tx.Rollback(ctx); if err != nil {
-		return fmt.Errorf("Erro ao dar rollback na transação: %w", err)
-	}
+	defer tx.Rollback(ctx)
 
 	orderQuery := `
 		INSERT INTO orders (id, customer_id, status, total, currency, created_at, updated_at) 
@@ -249,61 +139,203 @@ func (r *PostgresOrderRepository) CreateOrder(ctx context.Context, order *model.
 		Items:      eventItems,
 	}
 
+	if err := enqueueOutbox(ctx, tx, order.ID, events.OrderCreatedType, model.OutboxDestinationKafka, events.OrdersTopic, event); err != nil {
+		return err
+	}
+
+	notificationPayload := &messaging.NotificationPayload{
+		OrderID:    order.ID.String(),
+		CustomerID: order.CustomerID.String(),
+		Message:    "Seu pedido foi recebido com sucesso!",
+	}
+	if err := enqueueOutbox(ctx, tx, order.ID, messaging.NotificationType, model.OutboxDestinationRabbitMQ, messaging.EmailNotificationsQueue, notificationPayload); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("erro ao comitar transação: %w", err)
 	}
 
-	go func() {
-		err := r.KafkaProducer.PublishOrderCreated(context.Background(), event)
-		if err != nil {
-			log.Printf("ERRO ao publicar evento OrderCreated no Kafka: %v", err)
-		}
-	}()
+	r.invalidateOrder(ctx, order.ID)
 
-	go func() {
-		notificationPayload := &messaging.NotificationPayload{
-			OrderID:    order.ID.String(),
-			CustomerID: order.CustomerID.String(),
-			Message:    "Seu pedido foi recebido com sucesso!",
-		}
-		body, err := json.Marshal(notificationPayload)
-		if err != nil {
-			log.Printf("ERRO ao serializar mensagem para o RabbitMQ: %v", err)
-			return
-		}
+	return nil
+}
 
-		err = r.RabbitMQProducer.Publish(context.Background(), "email_notifications", body)
-		if err != nil {
-			log.Printf("ERRO ao publicar tarefa no RabbitMQ: %v", err)
-		}
-	}()
+// UpdateOrder aplica a transição de status validando-a contra o status atual e
+// devolve o status anterior. A escrita é um compare-and-set: se outra requisição
+// alterar o pedido entre a leitura e o UPDATE, retorna ErrOrderStatusConflict.
+// Os eventos de ciclo de vida são gravados no outbox na mesma transação.
+func (r *PostgresOrderRepository) UpdateOrder(ctx context.Context, id uuid.UUID, status model.Status, actor uuid.UUID) (model.Status, error) {
+	return r.transition(ctx, id, status, actor, "", "")
+}
 
-	return nil
+// ConfirmOrder confirma o pedido pendente depois da reserva de estoque e avisa
+// o cliente pela fila de notificações, na mesma transação da mudança de status.
+func (r *PostgresOrderRepository) ConfirmOrder(ctx context.Context, id uuid.UUID) (model.Status, error) {
+	return r.transition(ctx, id, model.StatusConfirmed, uuid.Nil, "", "Seu pedido foi confirmado.", model.StatusPending)
+}
+
+// CancelOrder cancela o pedido registrando o motivo e avisa o cliente pela
+// fila de notificações, tudo na mesma transação da mudança de status.
+func (r *PostgresOrderRepository) CancelOrder(ctx context.Context, id uuid.UUID, reason string, actor uuid.UUID) (model.Status, error) {
+	return r.transition(ctx, id, model.StatusCancelled, actor, reason, cancellationNotice(reason))
 }
 
-func (r *PostgresOrderRepository) UpdateOrder(ctx context.Context, id uuid.UUID, status model.Status) error {
+// ExpireOrder cancela o pedido cuja reserva de estoque venceu, mas só enquanto
+// ele ainda não foi pago; um pedido pago no meio tempo continua como está e o
+// retorno é um *model.TransitionError.
+func (r *PostgresOrderRepository) ExpireOrder(ctx context.Context, id uuid.UUID, reason string) (model.Status, error) {
+	return r.transition(ctx, id, model.StatusCancelled, uuid.Nil, reason, cancellationNotice(reason), model.StatusPending, model.StatusConfirmed)
+}
+
+// RefundOrder estorna o pedido pago cujo estoque não pôde ser efetivado e avisa
+// o cliente com o motivo. Pedidos que não estão pagos ficam como estão e o
+// retorno é um *model.TransitionError.
+func (r *PostgresOrderRepository) RefundOrder(ctx context.Context, id uuid.UUID, reason string) (model.Status, error) {
+	return r.transition(ctx, id, model.StatusRefunded, uuid.Nil, reason, "Seu pagamento foi estornado: "+reason, model.StatusPaid)
+}
+
+func cancellationNotice(reason string) string {
+	if reason == "" {
+		return ""
+	}
+	return "Seu pedido foi cancelado: " + reason
+}
+
+// transition aplica a mudança de status e, se notice não for vazio, o envia ao
+// cliente. Se onlyFrom for informado, o status atual também precisa estar nessa
+// lista.
+func (r *PostgresOrderRepository) transition(ctx context.Context, id uuid.UUID, status model.Status, actor uuid.UUID, reason, notice string, onlyFrom ...model.Status) (model.Status, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var current model.Status
+	var customerID uuid.UUID
+	err = tx.QueryRow(ctx, `SELECT status, customer_id FROM orders WHERE id = $1`, id).Scan(&current, &customerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", pgx.ErrNoRows
+		}
+		return "", fmt.Errorf("erro ao buscar status do pedido: %w", err)
+	}
+
+	if !current.CanTransitionTo(status) || (len(onlyFrom) > 0 && !slices.Contains(onlyFrom, current)) {
+		return current, &model.TransitionError{From: current, To: status}
+	}
+
 	query := `
-		UPDATE orders SET status = $1, updated_at = $2 WHERE id = $3 
+		UPDATE orders SET status = $1, cancellation_reason = NULLIF($2, ''), updated_at = $3 WHERE id = $4 AND status = $5
 	`
 
-	tag, err := r.DB.Exec(ctx, query, status, time.Now(), id)
+	now := time.Now().UTC()
+	tag, err := tx.Exec(ctx, query, status, reason, now, id, current)
 	if err != nil {
-		return fmt.Errorf("erro ao atualizar a tabela orders: %w", err)
+		return current, fmt.Errorf("erro ao atualizar a tabela orders: %w", err)
 	}
 
 	if tag.RowsAffected() == 0 {
-		return pgx.ErrNoRows
+		return current, ErrOrderStatusConflict
 	}
 
-	return nil
+	statusChanged := events.OrderStatusChangedEvent{
+		Version:        events.OrderLifecycleVersion,
+		OrderID:        id,
+		CustomerID:     customerID,
+		PreviousStatus: current,
+		NewStatus:      status,
+		Actor:          actor,
+		OccurredAt:     now,
+	}
+	if err := enqueueOutbox(ctx, tx, id, events.OrderStatusChangedType, model.OutboxDestinationKafka, events.OrdersTopic, statusChanged); err != nil {
+		return current, err
+	}
+
+	if status == model.StatusCancelled || status == model.StatusRefunded {
+		items, err := findEventItems(ctx, tx, id)
+		if err != nil {
+			return current, err
+		}
+
+		if status == model.StatusCancelled {
+			cancelled := events.OrderCancelledEvent{
+				Version:        events.OrderLifecycleVersion,
+				OrderID:        id,
+				CustomerID:     customerID,
+				PreviousStatus: current,
+				Reason:         reason,
+				Actor:          actor,
+				OccurredAt:     now,
+				Items:          items,
+			}
+			if err := enqueueOutbox(ctx, tx, id, events.OrderCancelledType, model.OutboxDestinationKafka, events.OrdersTopic, cancelled); err != nil {
+				return current, err
+			}
+		} else {
+			refunded := events.OrderRefundedEvent{
+				Version:        events.OrderLifecycleVersion,
+				OrderID:        id,
+				CustomerID:     customerID,
+				PreviousStatus: current,
+				Actor:          actor,
+				OccurredAt:     now,
+				Items:          items,
+			}
+			if err := enqueueOutbox(ctx, tx, id, events.OrderRefundedType, model.OutboxDestinationKafka, events.OrdersTopic, refunded); err != nil {
+				return current, err
+			}
+		}
+	}
+
+	if notice != "" {
+		notificationPayload := &messaging.NotificationPayload{
+			OrderID:    id.String(),
+			CustomerID: customerID.String(),
+			Message:    notice,
+		}
+		if err := enqueueOutbox(ctx, tx, id, messaging.NotificationType, model.OutboxDestinationRabbitMQ, messaging.EmailNotificationsQueue, notificationPayload); err != nil {
+			return current, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return current, fmt.Errorf("erro ao comitar transação: %w", err)
+	}
+
+	r.invalidateOrder(ctx, id)
+
+	return current, nil
 }
 
-func (r *PostgresOrderRepository) DeleteOrder(ctx context.Context, id uuid.UUID) error {
+func (r *PostgresOrderRepository) DeleteOrder(ctx context.Context, id uuid.UUID, actor uuid.UUID) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var current model.Status
+	var customerID uuid.UUID
+	err = tx.QueryRow(ctx, `SELECT status, customer_id FROM orders WHERE id = $1 FOR UPDATE`, id).Scan(&current, &customerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pgx.ErrNoRows
+		}
+		return fmt.Errorf("erro ao buscar o pedido: %w", err)
+	}
+
+	items, err := findEventItems(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		DELETE FROM orders WHERE id = $1
 	`
 
-	tag, err := r.DB.Exec(ctx, query, id)
+	tag, err := tx.Exec(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("erro ao excluir ordem: %w", err)
 	}
@@ -312,5 +344,46 @@ func (r *PostgresOrderRepository) DeleteOrder(ctx context.Context, id uuid.UUID)
 		return pgx.ErrNoRows
 	}
 
+	deleted := events.OrderDeletedEvent{
+		Version:        events.OrderLifecycleVersion,
+		OrderID:        id,
+		CustomerID:     customerID,
+		PreviousStatus: current,
+		Actor:          actor,
+		OccurredAt:     time.Now().UTC(),
+		Items:          items,
+	}
+	if err := enqueueOutbox(ctx, tx, id, events.OrderDeletedType, model.OutboxDestinationKafka, events.OrdersTopic, deleted); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("erro ao comitar transação: %w", err)
+	}
+
+	r.invalidateOrder(ctx, id)
+
 	return nil
 }
+
+func findEventItems(ctx context.Context, tx pgx.Tx, orderID uuid.UUID) ([]events.OrderItemCreated, error) {
+	rows, err := tx.Query(ctx, `SELECT product_id, quantity FROM order_items WHERE order_id = $1`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar os itens do pedido: %w", err)
+	}
+	defer rows.Close()
+
+	items := []events.OrderItemCreated{}
+	for rows.Next() {
+		var item events.OrderItemCreated
+		if err := rows.Scan(&item.ProductID, &item.Quantity); err != nil {
+			return nil, fmt.Errorf("erro ao escanear item do pedido: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante a leitura dos itens do pedido: %w", err)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mlucas4330/orderflow-pro/internal/cache"
+	redis "github.com/redis/go-redis/v9"
+)
+
+// ordersListVersionKey guarda a geração atual das listagens em cache. As
+// chaves de listagem incluem essa versão, então incrementá-la invalida todas as
+// combinações de filtro de uma vez; as chaves antigas somem pelo TTL.
+const ordersListVersionKey = "orders:list:version"
+
+var (
+	orderCachePolicy      = cache.Policy{TTL: 10 * time.Minute, StaleTTL: time.Minute, Local: true}
+	ordersListCachePolicy = cache.Policy{TTL: 30 * time.Second, StaleTTL: 30 * time.Second}
+)
+
+func orderCacheKey(id uuid.UUID) string {
+	return fmt.Sprintf("order:%s", id.String())
+}
+
+// ordersListCacheKey devolve a chave da listagem na versão atual. Se a versão
+// não puder ser lida, ok é false e a consulta deve ignorar o cache, para não
+// servir uma listagem de uma geração já invalidada.
+func (r *PostgresOrderRepository) ordersListCacheKey(ctx context.Context, filter OrderFilter) (string, bool) {
+	version, err := r.Redis.Get(ctx, ordersListVersionKey).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		if !errors.Is(err, cache.ErrCircuitOpen) {
+			log.Printf("Erro ao ler a versão das listagens de pedidos no Redis: %v", err)
+		}
+		return "", false
+	}
+
+	return fmt.Sprintf("orders:list:v%d:%s", version, filter.cacheHash()), true
+}
+
+// invalidateOrder descarta o pedido do cache e avança a versão das listagens.
+// Deve ser chamada depois do commit, para que uma leitura concorrente não
+// recoloque no cache o estado anterior à escrita.
+func (r *PostgresOrderRepository) invalidateOrder(ctx context.Context, id uuid.UUID) {
+	ctx = context.WithoutCancel(ctx)
+
+	if err := r.Cache.Delete(ctx, orderCacheKey(id)); err != nil {
+		log.Printf("AVISO: Falha ao invalidar o cache do pedido %s: %v", id, err)
+	}
+	if err := r.Redis.Incr(ctx, ordersListVersionKey).Err(); err != nil {
+		log.Printf("AVISO: Falha ao invalidar as listagens de pedidos no cache: %v", err)
+	}
+}
+
+// RecoverCache compensa as escritas feitas enquanto o Redis estava fora:
+// reenvia as invalidações de pedidos que falharam e avança a versão das
+// listagens, já que não dá para saber quais delas ficaram desatualizadas. Deve
+// ser registrada no breaker com OnRecover.
+func (r *PostgresOrderRepository) RecoverCache(ctx context.Context) error {
+	if err := r.Cache.Recover(ctx); err != nil {
+		return fmt.Errorf("erro ao reenviar as invalidações do cache: %w", err)
+	}
+	if err := r.Redis.Incr(ctx, ordersListVersionKey).Err(); err != nil {
+		return fmt.Errorf("erro ao invalidar as listagens de pedidos no cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mlucas4330/orderflow-pro/internal/cache"
+	"github.com/mlucas4330/orderflow-pro/pkg/model"
+	"github.com/shopspring/decimal"
+)
+
+// OrderSortField é uma coluna pela qual a listagem de pedidos pode ser
+// ordenada. Só os valores de orderSortColumns são aceitos.
+type OrderSortField string
+
+const (
+	SortByCreatedAt OrderSortField = "created_at"
+	SortByUpdatedAt OrderSortField = "updated_at"
+	SortByTotal     OrderSortField = "total"
+)
+
+var orderSortColumns = map[OrderSortField]string{
+	SortByCreatedAt: "timestamptz",
+	SortByUpdatedAt: "timestamptz",
+	SortByTotal:     "numeric",
+}
+
+func (f OrderSortField) IsValid() bool {
+	_, ok := orderSortColumns[f]
+	return ok
+}
+
+var ErrInvalidCursor = errors.New("cursor de paginação inválido")
+
+const (
+	DefaultOrdersPageSize = 20
+	MaxOrdersPageSize     = 100
+)
+
+// OrderFilter descreve uma página da listagem de pedidos. A paginação é por
+// keyset sobre (SortBy, id); Cursor é o next_cursor devolvido pela página
+// anterior e só vale para a mesma ordenação. Limit fora de 1..MaxOrdersPageSize
+// é ajustado: zero ou negativo vira DefaultOrdersPageSize.
+type OrderFilter struct {
+	Statuses    []model.Status   `json:"statuses,omitempty"`
+	CustomerID  *uuid.UUID       `json:"customer_id,omitempty"`
+	CreatedFrom *time.Time       `json:"created_from,omitempty"`
+	CreatedTo   *time.Time       `json:"created_to,omitempty"`
+	MinTotal    *decimal.Decimal `json:"min_total,omitempty"`
+	MaxTotal    *decimal.Decimal `json:"max_total,omitempty"`
+	SortBy      OrderSortField   `json:"sort_by"`
+	Descending  bool             `json:"descending"`
+	Cursor      string           `json:"cursor,omitempty"`
+	Limit       int              `json:"limit"`
+}
+
+type OrderPage struct {
+	Orders     []model.Order `json:"orders"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+type orderCursor struct {
+	SortBy     OrderSortField `json:"s"`
+	Descending bool           `json:"d"`
+	Value      string         `json:"v"`
+	ID         uuid.UUID      `json:"id"`
+}
+
+func (c orderCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeOrderCursor(raw string) (*orderCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor orderCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || !cursor.SortBy.IsValid() {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// cacheHash identifica a consulta no cache, para que cada combinação de
+// filtros, ordenação e página tenha o seu próprio registro.
+func (f OrderFilter) cacheHash() string {
+	data, _ := json.Marshal(f)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (r *PostgresOrderRepository) FindOrders(ctx context.Context, filter OrderFilter) (*OrderPage, error) {
+	if filter.SortBy == "" {
+		filter.SortBy = SortByCreatedAt
+	}
+	if !filter.SortBy.IsValid() {
+		return nil, fmt.Errorf("ordenação não permitida: %s", filter.SortBy)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultOrdersPageSize
+	}
+	filter.Limit = min(filter.Limit, MaxOrdersPageSize)
+
+	var cursor *orderCursor
+	if filter.Cursor != "" {
+		decoded, err := decodeOrderCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if decoded.SortBy != filter.SortBy || decoded.Descending != filter.Descending {
+			return nil, ErrInvalidCursor
+		}
+		cursor = decoded
+	}
+
+	key, cacheable := r.ordersListCacheKey(ctx, filter)
+	if !cacheable {
+		return r.findOrders(ctx, filter, cursor)
+	}
+
+	return cache.Fetch(ctx, r.Cache, key, ordersListCachePolicy, func(ctx context.Context) (*OrderPage, error) {
+		return r.findOrders(ctx, filter, cursor)
+	})
+}
+
+func (r *PostgresOrderRepository) findOrders(ctx context.Context, filter OrderFilter, cursor *orderCursor) (*OrderPage, error) {
+	query, args := buildOrdersQuery(filter, cursor)
+	orderRows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar pedidos: %w", err)
+	}
+	defer orderRows.Close()
+
+	orders := []model.Order{}
+	for orderRows.Next() {
+		var order model.Order
+		err := orderRows.Scan(
+			&order.ID, &order.CustomerID, &order.Status, &order.CancellationReason, &order.Total,
+			&order.Currency, &order.CreatedAt, &order.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear pedido: %w", err)
+		}
+		orders = append(orders, order)
+	}
+	if err = orderRows.Err(); err != nil {
+		return nil, fmt.Errorf("erro na iteração dos pedidos: %w", err)
+	}
+
+	page := &OrderPage{Orders: orders}
+
+	// A consulta busca um pedido a mais para saber se existe próxima página.
+	if len(orders) > filter.Limit {
+		page.Orders = orders[:filter.Limit]
+		last := page.Orders[filter.Limit-1]
+		page.NextCursor = orderCursor{
+			SortBy:     filter.SortBy,
+			Descending: filter.Descending,
+			Value:      sortValue(last, filter.SortBy),
+			ID:         last.ID,
+		}.encode()
+	}
+
+	if err := r.loadOrderItems(ctx, page.Orders); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func buildOrdersQuery(filter OrderFilter, cursor *orderCursor) (string, []any) {
+	var conditions []string
+	var args []any
+
+	arg := func(value any) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = string(status)
+		}
+		conditions = append(conditions, "status = ANY("+arg(statuses)+")")
+	}
+	if filter.CustomerID != nil {
+		conditions = append(conditions, "customer_id = "+arg(*filter.CustomerID))
+	}
+	if filter.CreatedFrom != nil {
+		conditions = append(conditions, "created_at >= "+arg(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		conditions = append(conditions, "created_at < "+arg(*filter.CreatedTo))
+	}
+	if filter.MinTotal != nil {
+		conditions = append(conditions, "total >= "+arg(*filter.MinTotal))
+	}
+	if filter.MaxTotal != nil {
+		conditions = append(conditions, "total <= "+arg(*filter.MaxTotal))
+	}
+
+	column := string(filter.SortBy)
+	direction, comparison := "ASC", ">"
+	if filter.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	if cursor != nil {
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s::%s, %s)",
+			column, comparison, arg(cursor.Value), orderSortColumns[filter.SortBy], arg(cursor.ID)))
+	}
+
+	query := `SELECT id, customer_id, status, cancellation_reason, total, currency, created_at, updated_at FROM orders`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %s", column, direction, direction, arg(filter.Limit+1))
+
+	return query, args
+}
+
+func sortValue(order model.Order, field OrderSortField) string {
+	switch field {
+	case SortByUpdatedAt:
+		return order.UpdatedAt.Format(time.RFC3339Nano)
+	case SortByTotal:
+		return order.Total.String()
+	default:
+		return order.CreatedAt.Format(time.RFC3339Nano)
+	}
+}
+
+func (r *PostgresOrderRepository) loadOrderItems(ctx context.Context, orders []model.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	orderIDs := make([]uuid.UUID, len(orders))
+	for i, order := range orders {
+		orderIDs[i] = order.ID
+	}
+
+	itemsQuery := `SELECT id, order_id, product_id, quantity, price_at_time FROM order_items WHERE order_id = ANY($1)`
+	itemRows, err := r.DB.Query(ctx, itemsQuery, orderIDs)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar itens dos pedidos: %w", err)
+	}
+	defer itemRows.Close()
+
+	itemsByOrderID := make(map[uuid.UUID][]model.OrderItem)
+	for itemRows.Next() {
+		var orderItem model.OrderItem
+		if err := itemRows.Scan(&orderItem.ID, &orderItem.OrderID, &orderItem.ProductID, &orderItem.Quantity, &orderItem.PriceAtTime); err != nil {
+			return fmt.Errorf("erro ao escanear item: %w", err)
+		}
+		itemsByOrderID[orderItem.OrderID] = append(itemsByOrderID[orderItem.OrderID], orderItem)
+	}
+	if err := itemRows.Err(); err != nil {
+		return fmt.Errorf("erro na iteração dos itens: %w", err)
+	}
+
+	for i, order := range orders {
+		if orderItems, ok := itemsByOrderID[order.ID]; ok {
+			orders[i].OrderItems = orderItems
+		} else {
+			orders[i].OrderItems = []model.OrderItem{}
+		}
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	pgx "github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/mlucas4330/orderflow-pro/internal/cache"
@@ -14,11 +15,10 @@ import (
 	"github.com/mlucas4330/orderflow-pro/pkg/model"
 	redis "github.com/redis/go-redis/v9"
 	"github.com/shopspring/decimal"
-	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
-func setupTest(t *testing.T) (*PostgresOrderRepository, *pgxpool.Pool, *redis.Client, *MockKafkaProducer, *MockRabbitMQProducer) {
+func setupTest(t *testing.T) (*PostgresOrderRepository, *pgxpool.Pool, redis.UniversalClient) {
 	cfg := config.LoadOrderConfig()
 
 	ctx := context.Background()
@@ -28,22 +28,17 @@ func setupTest(t *testing.T) (*PostgresOrderRepository, *pgxpool.Pool, *redis.Cl
 	dbpool, err := pgxpool.New(ctx, postgresDsn)
 	require.NoError(t, err, "Falha ao conectar ao banco de dados de teste")
 
-	redisClient, err := cache.NewRedisClient(ctx, cfg.RedisAddr, cfg.RedisDB)
-	require.NoError(t, err, "Falha ao conectar ao Redis de teste")
-
-	mockKafkaProducer := new(MockKafkaProducer)
-	defer mockKafkaProducer.Close()
-
-	mockRabbitProducer := new(MockRabbitMQProducer)
-	defer mockRabbitProducer.Close()
+	redisClient, _, err := cache.NewRedisClient(ctx, cfg.Redis)
+	require.NoError(t, err)
+	require.NoError(t, redisClient.Ping(ctx).Err(), "Falha ao conectar ao Redis de teste")
 
-	repo := NewOrderRepository(dbpool, redisClient, mockKafkaProducer, mockRabbitProducer)
+	repo := NewOrderRepository(dbpool, redisClient)
 
-	return repo, dbpool, redisClient, mockKafkaProducer, mockRabbitProducer
+	return repo, dbpool, redisClient
 }
 
-func cleanup(t *testing.T, dbpool *pgxpool.Pool, redisClient *redis.Client) {
-	_, err := dbpool.Exec(context.Background(), "TRUNCATE TABLE order_items, orders RESTART IDENTITY")
+func cleanup(t *testing.T, dbpool *pgxpool.Pool, redisClient redis.UniversalClient) {
+	_, err := dbpool.Exec(context.Background(), "TRUNCATE TABLE order_items, orders, outbox RESTART IDENTITY")
 	require.NoError(t, err)
 
 	err = redisClient.FlushDB(context.Background()).Err()
@@ -51,7 +46,7 @@ func cleanup(t *testing.T, dbpool *pgxpool.Pool, redisClient *redis.Client) {
 }
 
 func TestCreateAndFindOrder(t *testing.T) {
-	repo, dbpool, redisClient, mockKafka, mockRabbit := setupTest(t)
+	repo, dbpool, redisClient := setupTest(t)
 	t.Cleanup(func() {
 		cleanup(t, dbpool, redisClient)
 		dbpool.Close()
@@ -83,9 +78,6 @@ func TestCreateAndFindOrder(t *testing.T) {
 		},
 	}
 
-	mockKafka.On("PublishOrderCreated", mock.Anything, mock.AnythingOfType("events.OrderCreatedEvent")).Return(nil)
-	mockRabbit.On("Publish", mock.Anything, "email_notifications", mock.AnythingOfType("[]uint8")).Return(nil)
-
 	err := repo.CreateOrder(ctx, order, items)
 
 	require.NoError(t, err, "CreateOrder não deveria retornar um erro")
@@ -102,12 +94,22 @@ func TestCreateAndFindOrder(t *testing.T) {
 	require.Len(t, foundOrder.OrderItems, 1, "Deveria haver 1 item no pedido")
 	require.Equal(t, items[0].ProductID, foundOrder.OrderItems[0].ProductID, "O ProductID do item não bate")
 
-	mockKafka.AssertExpectations(t)
-	mockRabbit.AssertExpectations(t)
+	rows, err := dbpool.Query(ctx, "SELECT event_type, destination FROM outbox WHERE aggregate_id = $1 ORDER BY id", orderID)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var outboxEntries []string
+	for rows.Next() {
+		var eventType, destination string
+		require.NoError(t, rows.Scan(&eventType, &destination))
+		outboxEntries = append(outboxEntries, destination+":"+eventType)
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, []string{"kafka:order.created", "rabbitmq:notification.email"}, outboxEntries, "O pedido deveria gerar as mensagens do outbox na mesma transação")
 }
 
 func TestFindOrderCache(t *testing.T) {
-	repo, dbpool, redisClient, mockKafka, mockRabbit := setupTest(t)
+	repo, dbpool, redisClient := setupTest(t)
 	t.Cleanup(func() {
 		cleanup(t, dbpool, redisClient)
 		dbpool.Close()
@@ -137,9 +139,6 @@ func TestFindOrderCache(t *testing.T) {
 		},
 	}
 
-	mockKafka.On("PublishOrderCreated", mock.Anything, mock.AnythingOfType("events.OrderCreatedEvent")).Return(nil)
-	mockRabbit.On("Publish", mock.Anything, "email_notifications", mock.AnythingOfType("[]uint8")).Return(nil)
-
 	err := repo.CreateOrder(ctx, order, items)
 	require.NoError(t, err)
 
@@ -157,7 +156,280 @@ func TestFindOrderCache(t *testing.T) {
 	require.NoError(t, err, "A busca no cache não deveria dar erro, mesmo com o banco limpo")
 	require.NotNil(t, cachedOrder, "Deveria encontrar o pedido no cache")
 	require.Equal(t, orderID, cachedOrder.ID, "O ID do pedido do cache está incorreto")
+}
+
+func TestUpdateOrderStatusTransitions(t *testing.T) {
+	repo, dbpool, redisClient := setupTest(t)
+	t.Cleanup(func() {
+		cleanup(t, dbpool, redisClient)
+		dbpool.Close()
+		redisClient.Close()
+	})
+	ctx := context.Background()
+
+	orderID := uuid.New()
+	actor := uuid.New()
+	order := &model.Order{
+		ID:         orderID,
+		CustomerID: uuid.New(),
+		Status:     model.StatusPending,
+		Total:      decimal.NewFromFloat(19.99),
+		Currency:   "BRL",
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	items := []model.OrderItem{
+		{ID: uuid.New(), OrderID: orderID, ProductID: uuid.New(), Quantity: 1, PriceAtTime: decimal.NewFromFloat(19.99)},
+	}
+	require.NoError(t, repo.CreateOrder(ctx, order, items))
+
+	_, err := repo.UpdateOrder(ctx, orderID, model.StatusPaid, actor)
+	require.ErrorAs(t, err, new(*model.TransitionError), "Pedido pendente não pode ser pago antes da reserva de estoque")
+
+	previous, err := repo.UpdateOrder(ctx, orderID, model.StatusConfirmed, uuid.Nil)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusPending, previous)
+
+	_, err = repo.UpdateOrder(ctx, orderID, model.StatusPaid, actor)
+	require.NoError(t, err)
+
+	_, err = repo.UpdateOrder(ctx, orderID, model.StatusPending, actor)
+	var transitionErr *model.TransitionError
+	require.ErrorAs(t, err, &transitionErr, "Voltar de paid para pending deveria ser rejeitado")
+	require.Equal(t, model.StatusPaid, transitionErr.From)
+
+	_, err = repo.UpdateOrder(ctx, orderID, model.StatusCancelled, actor)
+	require.ErrorAs(t, err, &transitionErr, "Pedido pago só deveria sair de paid pelo envio ou reembolso")
+
+	_, err = repo.UpdateOrder(ctx, orderID, model.StatusRefunded, actor)
+	require.NoError(t, err)
+
+	var count int
+	err = dbpool.QueryRow(ctx, "SELECT count(*) FROM outbox WHERE aggregate_id = $1 AND event_type IN ('order.status_changed', 'order.refunded')", orderID).Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 4, count, "Deveria haver três eventos de mudança de status e um de reembolso")
+}
+
+func TestFindOrdersPaginatesAndFilters(t *testing.T) {
+	repo, dbpool, redisClient := setupTest(t)
+	t.Cleanup(func() {
+		cleanup(t, dbpool, redisClient)
+		dbpool.Close()
+		redisClient.Close()
+	})
+
+	ctx := context.Background()
+
+	customerID := uuid.New()
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	var created []uuid.UUID
+	for i := range 5 {
+		order := &model.Order{
+			ID:         uuid.New(),
+			CustomerID: customerID,
+			Status:     model.StatusPending,
+			Total:      decimal.NewFromInt(int64(10 * (i + 1))),
+			Currency:   "BRL",
+			CreatedAt:  base.Add(time.Duration(i) * time.Minute),
+			UpdatedAt:  base.Add(time.Duration(i) * time.Minute),
+		}
+		require.NoError(t, repo.CreateOrder(ctx, order, []model.OrderItem{}))
+		created = append(created, order.ID)
+	}
+
+	filter := OrderFilter{CustomerID: &customerID, SortBy: SortByCreatedAt, Limit: 2}
+
+	var seen []uuid.UUID
+	for {
+		page, err := repo.FindOrders(ctx, filter)
+		require.NoError(t, err)
+		for _, order := range page.Orders {
+			seen = append(seen, order.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	require.Equal(t, created, seen, "As páginas deveriam percorrer todos os pedidos em ordem, sem repetir")
+
+	minTotal := decimal.NewFromInt(20)
+	maxTotal := decimal.NewFromInt(40)
+	page, err := repo.FindOrders(ctx, OrderFilter{
+		CustomerID: &customerID,
+		MinTotal:   &minTotal,
+		MaxTotal:   &maxTotal,
+		SortBy:     SortByTotal,
+		Descending: true,
+		Limit:      10,
+	})
+	require.NoError(t, err)
+	require.Len(t, page.Orders, 3)
+	require.Equal(t, created[3], page.Orders[0].ID)
+	require.Empty(t, page.NextCursor)
+
+	_, err = repo.FindOrders(ctx, OrderFilter{SortBy: SortByTotal, Cursor: filter.Cursor, Limit: 2})
+	require.ErrorIs(t, err, ErrInvalidCursor, "Cursor de outra ordenação deveria ser rejeitado")
+}
+
+func TestFindOrdersClampsLimit(t *testing.T) {
+	repo, dbpool, redisClient := setupTest(t)
+	t.Cleanup(func() {
+		cleanup(t, dbpool, redisClient)
+		dbpool.Close()
+		redisClient.Close()
+	})
+
+	ctx := context.Background()
+
+	customerID := uuid.New()
+	for range 3 {
+		order := &model.Order{
+			ID:         uuid.New(),
+			CustomerID: customerID,
+			Status:     model.StatusPending,
+			Total:      decimal.NewFromInt(10),
+			Currency:   "BRL",
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+		require.NoError(t, repo.CreateOrder(ctx, order, []model.OrderItem{}))
+	}
+
+	for _, limit := range []int{0, -1, MaxOrdersPageSize + 1} {
+		page, err := repo.FindOrders(ctx, OrderFilter{CustomerID: &customerID, Limit: limit})
+		require.NoError(t, err, "Limit %d deveria ser ajustado, não causar erro", limit)
+		require.Len(t, page.Orders, 3)
+		require.Empty(t, page.NextCursor)
+	}
+}
+
+func TestOrderWritesInvalidateCache(t *testing.T) {
+	repo, dbpool, redisClient := setupTest(t)
+	t.Cleanup(func() {
+		cleanup(t, dbpool, redisClient)
+		dbpool.Close()
+		redisClient.Close()
+	})
+	ctx := context.Background()
+
+	customerID := uuid.New()
+	order := &model.Order{
+		ID:         uuid.New(),
+		CustomerID: customerID,
+		Status:     model.StatusPending,
+		Total:      decimal.NewFromInt(50),
+		Currency:   "BRL",
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	require.NoError(t, repo.CreateOrder(ctx, order, []model.OrderItem{}))
+
+	filter := OrderFilter{CustomerID: &customerID, SortBy: SortByCreatedAt, Limit: 10}
+
+	cached, err := repo.FindOrderById(ctx, order.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusPending, cached.Status)
+	page, err := repo.FindOrders(ctx, filter)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusPending, page.Orders[0].Status)
+
+	_, err = repo.CancelOrder(ctx, order.ID, "cliente desistiu", customerID)
+	require.NoError(t, err)
+
+	found, err := repo.FindOrderById(ctx, order.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusCancelled, found.Status, "A leitura após a escrita não deveria vir do cache antigo")
+	page, err = repo.FindOrders(ctx, filter)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusCancelled, page.Orders[0].Status, "A escrita deveria invalidar todas as listagens")
+
+	require.NoError(t, repo.DeleteOrder(ctx, order.ID, customerID))
+
+	_, err = repo.FindOrderById(ctx, order.ID)
+	require.ErrorIs(t, err, pgx.ErrNoRows, "Pedido excluído não deveria continuar legível pelo cache")
+	page, err = repo.FindOrders(ctx, filter)
+	require.NoError(t, err)
+	require.Empty(t, page.Orders)
+}
+
+func TestOrderCacheRecoversInvalidationsLostDuringOutage(t *testing.T) {
+	repo, dbpool, redisClient := setupTest(t)
+	t.Cleanup(func() {
+		cleanup(t, dbpool, redisClient)
+		dbpool.Close()
+		redisClient.Close()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	breaker := cache.NewBreaker(redisClient)
+	breaker.ProbeInterval = 20 * time.Millisecond
+	breaker.OnRecover(repo.RecoverCache)
+
+	customerID := uuid.New()
+	order := &model.Order{
+		ID:         uuid.New(),
+		CustomerID: customerID,
+		Status:     model.StatusPending,
+		Total:      decimal.NewFromInt(50),
+		Currency:   "BRL",
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	require.NoError(t, repo.CreateOrder(ctx, order, []model.OrderItem{}))
+
+	filter := OrderFilter{CustomerID: &customerID, SortBy: SortByCreatedAt, Limit: 10}
+	_, err := repo.FindOrderById(ctx, order.ID)
+	require.NoError(t, err)
+	_, err = repo.FindOrders(ctx, filter)
+	require.NoError(t, err)
+
+	breaker.Trip()
+	_, err = repo.CancelOrder(ctx, order.ID, "cliente desistiu", customerID)
+	require.NoError(t, err)
+
+	go breaker.Run(ctx)
+	require.Eventually(t, breaker.Available, 2*time.Second, 20*time.Millisecond)
+
+	found, err := repo.FindOrderById(ctx, order.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusCancelled, found.Status, "A invalidação perdida na queda do Redis deveria ser reenviada na volta")
+	page, err := repo.FindOrders(ctx, filter)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusCancelled, page.Orders[0].Status, "As listagens deveriam ser invalidadas na volta do Redis")
+}
+
+func TestConfirmOrderNotifiesCustomer(t *testing.T) {
+	repo, dbpool, redisClient := setupTest(t)
+	t.Cleanup(func() {
+		cleanup(t, dbpool, redisClient)
+		dbpool.Close()
+		redisClient.Close()
+	})
+	ctx := context.Background()
+
+	order := &model.Order{
+		ID:         uuid.New(),
+		CustomerID: uuid.New(),
+		Status:     model.StatusPending,
+		Total:      decimal.NewFromInt(10),
+		Currency:   "BRL",
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	require.NoError(t, repo.CreateOrder(ctx, order, []model.OrderItem{}))
+
+	previous, err := repo.ConfirmOrder(ctx, order.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusPending, previous)
+
+	var message string
+	err = dbpool.QueryRow(ctx, "SELECT convert_from(payload, 'UTF8')::jsonb->>'message' FROM outbox WHERE aggregate_id = $1 AND destination = 'rabbitmq' ORDER BY id DESC LIMIT 1", order.ID).Scan(&message)
+	require.NoError(t, err)
+	require.Equal(t, "Seu pedido foi confirmado.", message, "A confirmação deveria avisar o cliente na mesma transação")
 
-	mockKafka.AssertExpectations(t)
-	mockRabbit.AssertExpectations(t)
+	_, err = repo.ConfirmOrder(ctx, order.ID)
+	var transitionErr *model.TransitionError
+	require.ErrorAs(t, err, &transitionErr, "Só pedidos pendentes deveriam ser confirmados")
 }
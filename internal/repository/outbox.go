@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mlucas4330/orderflow-pro/pkg/model"
+)
+
+type OutboxRepository interface {
+	ClaimPending(ctx context.Context, topics []string, limit int, lease time.Duration) ([]model.OutboxMessage, error)
+	MarkPublished(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, cause error, retryAt time.Time) error
+	MarkParked(ctx context.Context, id int64, cause error) error
+	OldestPendingCreatedAt(ctx context.Context, topics []string) (*time.Time, error)
+}
+
+type PostgresOutboxRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewOutboxRepository(dbpool *pgxpool.Pool) *PostgresOutboxRepository {
+	return &PostgresOutboxRepository{DB: dbpool}
+}
+
+// ClaimPending reserva, por um período de lease, apenas a mensagem mais antiga
+// ainda não publicada de cada agregado. Mensagens seguintes do mesmo agregado
+// só ficam disponíveis depois que a anterior for publicada, o que garante a
+// ordem por pedido mesmo com várias réplicas do relay. Só são reservadas
+// mensagens dos tópicos informados, mas a ordem considera todos os tópicos.
+// Mensagens estacionadas não são reservadas e continuam segurando as seguintes
+// do mesmo agregado, que só andam depois que a estacionada for republicada.
+func (r *PostgresOutboxRepository) ClaimPending(ctx context.Context, topics []string, limit int, lease time.Duration) ([]model.OutboxMessage, error) {
+	query := `
+		UPDATE outbox SET next_attempt_at = $1
+		WHERE id IN (
+			SELECT o.id FROM outbox o
+			WHERE o.published_at IS NULL
+			  AND o.parked_at IS NULL
+			  AND o.next_attempt_at <= $2
+			  AND o.topic = ANY($4)
+			  AND NOT EXISTS (
+				SELECT 1 FROM outbox prev
+				WHERE prev.aggregate_id = o.aggregate_id
+				  AND prev.published_at IS NULL
+				  AND prev.id < o.id
+			  )
+			ORDER BY o.id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, aggregate_id, event_type, destination, topic, payload, attempts, last_error, next_attempt_at, created_at, published_at, parked_at
+	`
+
+	now := time.Now()
+	rows, err := r.DB.Query(ctx, query, now.Add(lease), now, limit, topics)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao reservar mensagens do outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []model.OutboxMessage
+	for rows.Next() {
+		var msg model.OutboxMessage
+		if err := rows.Scan(
+			&msg.ID, &msg.AggregateID, &msg.EventType, &msg.Destination, &msg.Topic, &msg.Payload,
+			&msg.Attempts, &msg.LastError, &msg.NextAttemptAt, &msg.CreatedAt, &msg.PublishedAt, &msg.ParkedAt,
+		); err != nil {
+			return nil, fmt.Errorf("erro ao escanear mensagem do outbox: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro na iteração das mensagens do outbox: %w", err)
+	}
+
+	return messages, nil
+}
+
+func (r *PostgresOutboxRepository) MarkPublished(ctx context.Context, id int64) error {
+	query := `
+		UPDATE outbox SET published_at = $1, attempts = attempts + 1, last_error = NULL WHERE id = $2
+	`
+
+	tag, err := r.DB.Exec(ctx, query, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("erro ao marcar mensagem do outbox como publicada: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *PostgresOutboxRepository) MarkFailed(ctx context.Context, id int64, cause error, retryAt time.Time) error {
+	query := `
+		UPDATE outbox SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2 WHERE id = $3
+	`
+
+	tag, err := r.DB.Exec(ctx, query, cause.Error(), retryAt, id)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar falha da mensagem do outbox: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+// MarkParked tira a mensagem da fila depois de esgotadas as tentativas. Ela fica
+// na tabela com o último erro para ser investigada e republicada manualmente
+// (limpando parked_at); até lá, as mensagens seguintes do agregado esperam.
+func (r *PostgresOutboxRepository) MarkParked(ctx context.Context, id int64, cause error) error {
+	query := `
+		UPDATE outbox SET attempts = attempts + 1, last_error = $1, parked_at = $2 WHERE id = $3
+	`
+
+	tag, err := r.DB.Exec(ctx, query, cause.Error(), time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("erro ao estacionar mensagem do outbox: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *PostgresOutboxRepository) OldestPendingCreatedAt(ctx context.Context, topics []string) (*time.Time, error) {
+	query := `
+		SELECT created_at FROM outbox WHERE published_at IS NULL AND parked_at IS NULL AND topic = ANY($1) ORDER BY id LIMIT 1
+	`
+
+	var createdAt time.Time
+	err := r.DB.QueryRow(ctx, query, topics).Scan(&createdAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erro ao buscar mensagem pendente mais antiga do outbox: %w", err)
+	}
+
+	return &createdAt, nil
+}
+
+func enqueueOutbox(ctx context.Context, tx pgx.Tx, aggregateID uuid.UUID, eventType string, destination model.OutboxDestination, topic string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar mensagem %s para o outbox: %w", eventType, err)
+	}
+
+	query := `
+		INSERT INTO outbox (aggregate_id, event_type, destination, topic, payload)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err = tx.Exec(ctx, query, aggregateID, eventType, destination, topic, body)
+	if err != nil {
+		return fmt.Errorf("erro ao inserir na tabela outbox: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mlucas4330/orderflow-pro/internal/events"
+	"github.com/mlucas4330/orderflow-pro/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParkedMessageBlocksItsAggregate(t *testing.T) {
+	_, dbpool, redisClient := setupTest(t)
+	t.Cleanup(func() {
+		cleanup(t, dbpool, redisClient)
+		dbpool.Close()
+		redisClient.Close()
+	})
+	ctx := context.Background()
+	repo := NewOutboxRepository(dbpool)
+	topics := []string{events.OrdersTopic}
+
+	blocked, other := uuid.New(), uuid.New()
+	for _, aggregateID := range []uuid.UUID{blocked, blocked, other} {
+		tx, err := dbpool.Begin(ctx)
+		require.NoError(t, err)
+		require.NoError(t, enqueueOutbox(ctx, tx, aggregateID, events.OrderCreatedType, model.OutboxDestinationKafka, events.OrdersTopic, map[string]string{}))
+		require.NoError(t, tx.Commit(ctx))
+	}
+
+	claimed, err := repo.ClaimPending(ctx, topics, 10, time.Millisecond)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2, "Só a primeira mensagem de cada agregado deveria ser reservada")
+	require.Equal(t, blocked, claimed[0].AggregateID)
+	require.NoError(t, repo.MarkParked(ctx, claimed[0].ID, errors.New("payload inválido")))
+	require.NoError(t, repo.MarkPublished(ctx, claimed[1].ID))
+
+	time.Sleep(5 * time.Millisecond)
+	claimed, err = repo.ClaimPending(ctx, topics, 10, time.Millisecond)
+	require.NoError(t, err)
+	require.Empty(t, claimed, "A mensagem estacionada deveria segurar as seguintes do mesmo pedido")
+
+	_, err = dbpool.Exec(ctx, "UPDATE outbox SET parked_at = NULL, next_attempt_at = now() WHERE parked_at IS NOT NULL")
+	require.NoError(t, err)
+	claimed, err = repo.ClaimPending(ctx, topics, 10, time.Millisecond)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1, "Republicada a mensagem estacionada, o agregado deveria voltar a andar")
+	require.Equal(t, blocked, claimed[0].AggregateID)
+}
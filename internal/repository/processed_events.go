@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	pgx "github.com/jackc/pgx/v5"
+)
+
+// markEventProcessed registra no ledger que o consumidor tratou o evento do
+// pedido. Deve ser chamada na mesma transação que aplica os efeitos do evento:
+// se retornar false, o evento já foi processado e a transação não deve fazer
+// mais nada.
+func markEventProcessed(ctx context.Context, tx pgx.Tx, consumer string, eventType string, orderID uuid.UUID, outcome string) (bool, error) {
+	query := `
+		INSERT INTO processed_events (consumer, event_type, order_id, outcome)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (consumer, event_type, order_id) DO NOTHING
+	`
+
+	tag, err := tx.Exec(ctx, query, consumer, eventType, orderID, outcome)
+	if err != nil {
+		return false, fmt.Errorf("erro ao registrar evento processado: %w", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
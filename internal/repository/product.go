@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mlucas4330/orderflow-pro/pkg/model"
+	"github.com/shopspring/decimal"
+)
+
+type ProductRepository interface {
+	FindProductById(ctx context.Context, id uuid.UUID) (*model.Product, error)
+	FindProductsByIds(ctx context.Context, ids []uuid.UUID) ([]model.Product, error)
+	FindProductBySku(ctx context.Context, sku string) (*model.Product, error)
+	ListProducts(ctx context.Context, filter ProductFilter) ([]model.Product, error)
+	CreateProduct(ctx context.Context, product *model.Product) error
+	UpdateProduct(ctx context.Context, id uuid.UUID, update ProductUpdate) (*model.Product, error)
+	FindPriceAt(ctx context.Context, productID uuid.UUID, at time.Time) (*model.ProductPrice, error)
+	ListPrices(ctx context.Context, productID uuid.UUID) ([]model.ProductPrice, error)
+	SchedulePrice(ctx context.Context, price *model.ProductPrice) error
+	ApplyDuePrices(ctx context.Context) (int, error)
+}
+
+// uniqueViolation é o SQLSTATE de violação de UNIQUE, disparado pelo índice de
+// products.sku.
+const uniqueViolation = "23505"
+
+var ErrDuplicateSKU = errors.New("já existe um produto com este SKU")
+
+// ProductFilter filtra e pagina a listagem do catálogo. A paginação é por
+// keyset sobre o SKU: AfterSKU é o último SKU da página anterior.
+type ProductFilter struct {
+	Active    *bool
+	Name      string
+	SKUPrefix string
+	AfterSKU  string
+	Limit     int
+}
+
+// ProductUpdate descreve uma alteração parcial; campos nil não são alterados.
+type ProductUpdate struct {
+	Name          *string
+	SKU           *string
+	Price         *decimal.Decimal
+	StockQuantity *int
+	IsActive      *bool
+}
+
+type PostgresProductRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewProductRepository(dbpool *pgxpool.Pool) *PostgresProductRepository {
+	return &PostgresProductRepository{DB: dbpool}
+}
+
+// selectProducts lê o preço vigente em product_prices em vez de products.price,
+// para que um preço programado valha assim que chega a sua vigência, sem
+// esperar o próximo ApplyDuePrices. Produtos sem histórico usam products.price.
+const selectProducts = `
+	SELECT p.id, p.name, p.sku, COALESCE((
+		SELECT pp.price FROM product_prices pp
+		WHERE pp.product_id = p.id AND pp.effective_from <= now()
+		ORDER BY pp.effective_from DESC
+		LIMIT 1
+	), p.price), p.stock_quantity, p.is_active, p.created_at, p.updated_at
+	FROM products p`
+
+func scanProduct(row pgx.Row) (*model.Product, error) {
+	var product model.Product
+	err := row.Scan(
+		&product.ID, &product.Name, &product.SKU, &product.Price, &product.StockQuantity,
+		&product.IsActive, &product.CreatedAt, &product.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *PostgresProductRepository) FindProductById(ctx context.Context, id uuid.UUID) (*model.Product, error) {
+	query := selectProducts + ` WHERE p.id = $1`
+
+	product, err := scanProduct(r.DB.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pgx.ErrNoRows
+		}
+		return nil, fmt.Errorf("erro ao buscar o produto: %w", err)
+	}
+
+	return product, nil
+}
+
+// FindProductsByIds busca vários produtos numa única consulta. IDs sem produto
+// correspondente são simplesmente omitidos do resultado.
+func (r *PostgresProductRepository) FindProductsByIds(ctx context.Context, ids []uuid.UUID) ([]model.Product, error) {
+	query := selectProducts + ` WHERE p.id = ANY($1)`
+
+	return r.queryProducts(ctx, query, ids)
+}
+
+func (r *PostgresProductRepository) FindProductBySku(ctx context.Context, sku string) (*model.Product, error) {
+	query := selectProducts + ` WHERE p.sku = $1`
+
+	product, err := scanProduct(r.DB.QueryRow(ctx, query, sku))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pgx.ErrNoRows
+		}
+		return nil, fmt.Errorf("erro ao buscar o produto: %w", err)
+	}
+
+	return product, nil
+}
+
+func (r *PostgresProductRepository) ListProducts(ctx context.Context, filter ProductFilter) ([]model.Product, error) {
+	var conditions []string
+	var args []any
+
+	arg := func(value any) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if filter.Active != nil {
+		conditions = append(conditions, "p.is_active = "+arg(*filter.Active))
+	}
+	if filter.Name != "" {
+		conditions = append(conditions, "p.name ILIKE '%' || "+arg(escapeLike(filter.Name))+" || '%'")
+	}
+	if filter.SKUPrefix != "" {
+		conditions = append(conditions, "p.sku LIKE "+arg(escapeLike(filter.SKUPrefix))+" || '%'")
+	}
+	if filter.AfterSKU != "" {
+		conditions = append(conditions, "p.sku > "+arg(filter.AfterSKU))
+	}
+
+	query := selectProducts
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY p.sku LIMIT " + arg(filter.Limit)
+
+	return r.queryProducts(ctx, query, args...)
+}
+
+// CreateProduct insere o produto e abre o seu histórico de preços na mesma
+// transação.
+func (r *PostgresProductRepository) CreateProduct(ctx context.Context, product *model.Product) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO products (id, name, sku, price, stock_quantity, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err = tx.Exec(ctx, query,
+		product.ID, product.Name, product.SKU, product.Price, product.StockQuantity,
+		product.IsActive, product.CreatedAt, product.UpdatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicateSKU
+		}
+		return fmt.Errorf("erro ao inserir na tabela products: %w", err)
+	}
+
+	if err := recordPrice(ctx, tx, product.ID, product.Price, product.CreatedAt); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("erro ao comitar transação: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateProduct aplica a alteração parcial. Uma mudança de preço também entra
+// no histórico, com vigência imediata.
+func (r *PostgresProductRepository) UpdateProduct(ctx context.Context, id uuid.UUID, update ProductUpdate) (*model.Product, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE products SET
+			name = COALESCE($2, name),
+			sku = COALESCE($3, sku),
+			price = COALESCE($4, price),
+			stock_quantity = COALESCE($5, stock_quantity),
+			is_active = COALESCE($6, is_active),
+			updated_at = $7
+		WHERE id = $1
+	`
+
+	now := time.Now().UTC()
+	tag, err := tx.Exec(ctx, query,
+		id, update.Name, update.SKU, update.Price, update.StockQuantity, update.IsActive, now,
+	)
+	if err == nil && tag.RowsAffected() == 0 {
+		err = pgx.ErrNoRows
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pgx.ErrNoRows
+		}
+		if isUniqueViolation(err) {
+			return nil, ErrDuplicateSKU
+		}
+		return nil, fmt.Errorf("erro ao atualizar a tabela products: %w", err)
+	}
+
+	if update.Price != nil {
+		if err := recordPrice(ctx, tx, id, *update.Price, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao comitar transação: %w", err)
+	}
+
+	// Relido depois do commit para devolver o preço vigente, que pode vir de um
+	// preço programado e não do products.price.
+	return r.FindProductById(ctx, id)
+}
+
+func (r *PostgresProductRepository) queryProducts(ctx context.Context, query string, args ...any) ([]model.Product, error) {
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar os produtos: %w", err)
+	}
+	defer rows.Close()
+
+	var products []model.Product
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler produto: %w", err)
+		}
+		products = append(products, *product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao buscar os produtos: %w", err)
+	}
+
+	return products, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mlucas4330/orderflow-pro/pkg/model"
+	"github.com/shopspring/decimal"
+)
+
+// foreignKeyViolation é o SQLSTATE de violação de chave estrangeira, disparado
+// quando o produto do preço não existe.
+const foreignKeyViolation = "23503"
+
+var ErrDuplicatePriceSchedule = errors.New("já existe um preço programado para este produto neste instante")
+
+// FindPriceAt devolve o preço vigente do produto no instante informado, ou
+// pgx.ErrNoRows se o produto ainda não tinha preço naquele momento.
+func (r *PostgresProductRepository) FindPriceAt(ctx context.Context, productID uuid.UUID, at time.Time) (*model.ProductPrice, error) {
+	query := `
+		SELECT product_id, price, effective_from, created_at
+		FROM product_prices
+		WHERE product_id = $1 AND effective_from <= $2
+		ORDER BY effective_from DESC
+		LIMIT 1
+	`
+
+	var price model.ProductPrice
+	err := r.DB.QueryRow(ctx, query, productID, at).Scan(&price.ProductID, &price.Price, &price.EffectiveFrom, &price.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pgx.ErrNoRows
+		}
+		return nil, fmt.Errorf("erro ao buscar o preço do produto: %w", err)
+	}
+
+	return &price, nil
+}
+
+// ListPrices devolve o histórico completo do produto, incluindo preços
+// programados para o futuro, do mais antigo para o mais recente, ou
+// pgx.ErrNoRows se o produto não existe.
+func (r *PostgresProductRepository) ListPrices(ctx context.Context, productID uuid.UUID) ([]model.ProductPrice, error) {
+	query := `
+		SELECT product_id, price, effective_from, created_at
+		FROM product_prices
+		WHERE product_id = $1
+		ORDER BY effective_from
+	`
+
+	rows, err := r.DB.Query(ctx, query, productID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar o histórico de preços: %w", err)
+	}
+	defer rows.Close()
+
+	var prices []model.ProductPrice
+	for rows.Next() {
+		var price model.ProductPrice
+		if err := rows.Scan(&price.ProductID, &price.Price, &price.EffectiveFrom, &price.CreatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao ler preço: %w", err)
+		}
+		prices = append(prices, price)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao buscar o histórico de preços: %w", err)
+	}
+
+	if len(prices) == 0 {
+		var exists bool
+		if err := r.DB.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)`, productID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("erro ao buscar o produto: %w", err)
+		}
+		if !exists {
+			return nil, pgx.ErrNoRows
+		}
+	}
+
+	return prices, nil
+}
+
+// SchedulePrice registra um preço com vigência futura. As leituras de produto já
+// o usam a partir de EffectiveFrom; ApplyDuePrices só mantém products.price em
+// dia.
+func (r *PostgresProductRepository) SchedulePrice(ctx context.Context, price *model.ProductPrice) error {
+	query := `
+		INSERT INTO product_prices (product_id, price, effective_from)
+		VALUES ($1, $2, $3)
+		RETURNING created_at
+	`
+
+	err := r.DB.QueryRow(ctx, query, price.ProductID, price.Price, price.EffectiveFrom).Scan(&price.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case foreignKeyViolation:
+				return pgx.ErrNoRows
+			case uniqueViolation:
+				return ErrDuplicatePriceSchedule
+			}
+		}
+		return fmt.Errorf("erro ao inserir na tabela product_prices: %w", err)
+	}
+
+	return nil
+}
+
+// ApplyDuePrices copia para products.price o preço vigente de cada produto
+// cujo valor atual está desatualizado e devolve quantos foram alterados.
+func (r *PostgresProductRepository) ApplyDuePrices(ctx context.Context) (int, error) {
+	query := `
+		WITH current_prices AS (
+			SELECT DISTINCT ON (product_id) product_id, price
+			FROM product_prices
+			WHERE effective_from <= $1
+			ORDER BY product_id, effective_from DESC
+		)
+		UPDATE products p SET price = c.price, updated_at = $1
+		FROM current_prices c
+		WHERE p.id = c.product_id AND p.price <> c.price
+	`
+
+	tag, err := r.DB.Exec(ctx, query, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("erro ao aplicar preços programados: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+func recordPrice(ctx context.Context, tx pgx.Tx, productID uuid.UUID, price decimal.Decimal, effectiveFrom time.Time) error {
+	query := `
+		INSERT INTO product_prices (product_id, price, effective_from)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (product_id, effective_from) DO UPDATE SET price = EXCLUDED.price
+	`
+
+	if _, err := tx.Exec(ctx, query, productID, price, effectiveFrom); err != nil {
+		return fmt.Errorf("erro ao registrar preço no histórico: %w", err)
+	}
+
+	return nil
+}
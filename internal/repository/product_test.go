@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/mlucas4330/orderflow-pro/pkg/model"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindProductById(t *testing.T) {
+	_, dbpool, redisClient := setupTest(t)
+	t.Cleanup(func() {
+		dbpool.Close()
+		redisClient.Close()
+	})
+	ctx := context.Background()
+
+	repo := NewProductRepository(dbpool)
+	productID := createTestProduct(t, dbpool, 8)
+
+	product, err := repo.FindProductById(ctx, productID)
+	require.NoError(t, err)
+	require.Equal(t, "TEST-"+productID.String(), product.SKU)
+	require.Equal(t, 8, product.StockQuantity)
+	require.True(t, product.IsActive)
+
+	_, err = repo.FindProductById(ctx, uuid.New())
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestFindProductsByIds(t *testing.T) {
+	_, dbpool, redisClient := setupTest(t)
+	t.Cleanup(func() {
+		dbpool.Close()
+		redisClient.Close()
+	})
+	ctx := context.Background()
+
+	repo := NewProductRepository(dbpool)
+	first := createTestProduct(t, dbpool, 1)
+	second := createTestProduct(t, dbpool, 2)
+
+	products, err := repo.FindProductsByIds(ctx, []uuid.UUID{first, second, uuid.New()})
+	require.NoError(t, err)
+	require.Len(t, products, 2)
+}
+
+func TestProductCatalogWrites(t *testing.T) {
+	_, dbpool, redisClient := setupTest(t)
+	repo := NewProductRepository(dbpool)
+	ctx := context.Background()
+
+	sku := "CAT-" + uuid.NewString()
+	product := &model.Product{
+		ID:            uuid.New(),
+		Name:          "Café de catálogo",
+		SKU:           sku,
+		Price:         decimal.RequireFromString("12.50"),
+		StockQuantity: 4,
+		IsActive:      true,
+		CreatedAt:     time.Now().UTC(),
+		UpdatedAt:     time.Now().UTC(),
+	}
+	t.Cleanup(func() {
+		_, err := dbpool.Exec(context.Background(), "DELETE FROM products WHERE sku LIKE 'CAT-%'")
+		require.NoError(t, err)
+		dbpool.Close()
+		redisClient.Close()
+	})
+
+	require.NoError(t, repo.CreateProduct(ctx, product))
+
+	duplicate := *product
+	duplicate.ID = uuid.New()
+	require.ErrorIs(t, repo.CreateProduct(ctx, &duplicate), ErrDuplicateSKU)
+
+	inactive := false
+	updated, err := repo.UpdateProduct(ctx, product.ID, ProductUpdate{IsActive: &inactive})
+	require.NoError(t, err)
+	require.False(t, updated.IsActive)
+	require.Equal(t, "Café de catálogo", updated.Name, "Campos omitidos não deveriam mudar")
+
+	found, err := repo.FindProductBySku(ctx, sku)
+	require.NoError(t, err)
+	require.Equal(t, product.ID, found.ID)
+
+	products, err := repo.ListProducts(ctx, ProductFilter{Active: &inactive, SKUPrefix: "CAT-", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, products, 1)
+}
+
+func TestScheduledPriceIsApplied(t *testing.T) {
+	_, dbpool, redisClient := setupTest(t)
+	t.Cleanup(func() {
+		dbpool.Close()
+		redisClient.Close()
+	})
+	ctx := context.Background()
+
+	repo := NewProductRepository(dbpool)
+	productID := createTestProduct(t, dbpool, 1)
+
+	past := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, repo.SchedulePrice(ctx, &model.ProductPrice{ProductID: productID, Price: decimal.RequireFromString("20"), EffectiveFrom: past}))
+	future := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	require.NoError(t, repo.SchedulePrice(ctx, &model.ProductPrice{ProductID: productID, Price: decimal.RequireFromString("30"), EffectiveFrom: future}))
+	require.ErrorIs(t, repo.SchedulePrice(ctx, &model.ProductPrice{ProductID: productID, Price: decimal.RequireFromString("31"), EffectiveFrom: future}), ErrDuplicatePriceSchedule)
+
+	product, err := repo.FindProductById(ctx, productID)
+	require.NoError(t, err)
+	require.True(t, decimal.RequireFromString("20").Equal(product.Price), "O preço vigente deveria valer antes de ApplyDuePrices")
+
+	_, err = repo.ApplyDuePrices(ctx)
+	require.NoError(t, err)
+
+	product, err = repo.FindProductById(ctx, productID)
+	require.NoError(t, err)
+	require.True(t, decimal.RequireFromString("20").Equal(product.Price), "Apenas o preço já vigente deveria ser aplicado")
+
+	price, err := repo.FindPriceAt(ctx, productID, future.Add(time.Minute))
+	require.NoError(t, err)
+	require.True(t, decimal.RequireFromString("30").Equal(price.Price))
+
+	prices, err := repo.ListPrices(ctx, productID)
+	require.NoError(t, err)
+	require.Len(t, prices, 2)
+
+	_, err = repo.ListPrices(ctx, uuid.New())
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
@@ -0,0 +1,68 @@
+package reservation
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mlucas4330/orderflow-pro/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var expiredReservations = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "orderflow_stock_reservations_expired_total",
+	Help: "Pedidos cuja reserva de estoque venceu sem pagamento.",
+})
+
+const defaultBatchSize = 100
+
+// Sweeper vence periodicamente as reservas de estoque cujo prazo passou. O
+// evento StockExpired de cada pedido é gravado no outbox pelo repositório.
+type Sweeper struct {
+	Repo      repository.InventoryRepository
+	Interval  time.Duration
+	BatchSize int
+}
+
+func NewSweeper(repo repository.InventoryRepository, interval time.Duration) *Sweeper {
+	return &Sweeper{Repo: repo, Interval: interval, BatchSize: defaultBatchSize}
+}
+
+func (s *Sweeper) Run(ctx context.Context) {
+	log.Printf("Sweeper de reservas de estoque iniciado (intervalo de %s).", s.Interval)
+
+	for {
+		expired, err := s.Sweep(ctx)
+		if err != nil {
+			log.Printf("Erro ao vencer reservas de estoque: %v", err)
+		}
+
+		// Lote cheio: pode haver mais reservas vencidas esperando.
+		if expired >= s.BatchSize && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Sweeper de reservas de estoque finalizado.")
+			return
+		case <-time.After(s.Interval):
+		}
+	}
+}
+
+// Sweep vence um lote de reservas e devolve quantos pedidos foram afetados.
+func (s *Sweeper) Sweep(ctx context.Context) (int, error) {
+	expired, err := s.Repo.ExpireReservations(ctx, s.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	if expired > 0 {
+		log.Printf("%d reservas de estoque vencidas.", expired)
+		expiredReservations.Add(float64(expired))
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,28 @@
+package reservation_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mlucas4330/orderflow-pro/internal/repository"
+	"github.com/mlucas4330/orderflow-pro/internal/reservation"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSweep(t *testing.T) {
+	mockRepo := new(repository.MockInventoryRepository)
+	sweeper := reservation.NewSweeper(mockRepo, 0)
+
+	mockRepo.On("ExpireReservations", mock.Anything, 100).Return(3, nil).Once()
+	expired, err := sweeper.Sweep(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, expired)
+
+	mockRepo.On("ExpireReservations", mock.Anything, 100).Return(0, errors.New("banco indisponível")).Once()
+	_, err = sweeper.Sweep(context.Background())
+	require.Error(t, err)
+
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,89 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/mlucas4330/orderflow-pro/internal/events"
+	"github.com/mlucas4330/orderflow-pro/internal/messaging/consumer"
+	"github.com/mlucas4330/orderflow-pro/internal/repository"
+	"github.com/mlucas4330/orderflow-pro/pkg/model"
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// OrderSaga conclui a criação do pedido a partir da resposta do inventário:
+// StockReserved confirma o pedido e avisa o cliente, StockRejected o cancela com o motivo
+// (ou o estorna, se já estava pago) e StockExpired cancela o pedido que não foi pago dentro do prazo da reserva.
+type OrderSaga struct {
+	OrderRepo repository.OrderRepository
+}
+
+func NewOrderSaga(orderRepo repository.OrderRepository) *OrderSaga {
+	return &OrderSaga{OrderRepo: orderRepo}
+}
+
+func (s *OrderSaga) HandleStockReserved(ctx context.Context, msg kafka.Message) error {
+	var event events.StockReservedEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		return consumer.Permanent(fmt.Errorf("erro ao desserializar evento StockReserved: %w", err))
+	}
+
+	_, err := s.OrderRepo.ConfirmOrder(ctx, event.OrderID)
+	return s.settle(event.OrderID, model.StatusConfirmed, err)
+}
+
+func (s *OrderSaga) HandleStockRejected(ctx context.Context, msg kafka.Message) error {
+	var event events.StockRejectedEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		return consumer.Permanent(fmt.Errorf("erro ao desserializar evento StockRejected: %w", err))
+	}
+
+	// Um pedido já pago só recebe StockRejected quando a reserva venceu antes
+	// do pagamento e o saldo foi para outro pedido: ele é estornado. Os demais
+	// ainda não foram pagos e são cancelados.
+	_, err := s.OrderRepo.RefundOrder(ctx, event.OrderID, event.Reason)
+	var transitionErr *model.TransitionError
+	if !errors.As(err, &transitionErr) || !transitionErr.From.CanTransitionTo(model.StatusCancelled) {
+		return s.settle(event.OrderID, model.StatusRefunded, err)
+	}
+
+	_, err = s.OrderRepo.CancelOrder(ctx, event.OrderID, event.Reason, uuid.Nil)
+	return s.settle(event.OrderID, model.StatusCancelled, err)
+}
+
+func (s *OrderSaga) HandleStockExpired(ctx context.Context, msg kafka.Message) error {
+	var event events.StockExpiredEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		return consumer.Permanent(fmt.Errorf("erro ao desserializar evento StockExpired: %w", err))
+	}
+
+	_, err := s.OrderRepo.ExpireOrder(ctx, event.OrderID, "prazo de pagamento expirado")
+	return s.settle(event.OrderID, model.StatusCancelled, err)
+}
+
+// settle trata como concluídos os pedidos que já saíram de pending (reentrega
+// ou ação manual anterior) e os que foram excluídos nesse meio tempo.
+func (s *OrderSaga) settle(orderID uuid.UUID, target model.Status, err error) error {
+	if err == nil {
+		log.Printf("Pedido %s movido para %s pela saga de estoque.", orderID, target)
+		return nil
+	}
+
+	var transitionErr *model.TransitionError
+	if errors.As(err, &transitionErr) {
+		log.Printf("Pedido %s já está em %s, evento de estoque ignorado.", orderID, transitionErr.From)
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("Pedido %s não encontrado, evento de estoque ignorado.", orderID)
+		return nil
+	}
+
+	return fmt.Errorf("erro ao mover o pedido %s para %s: %w", orderID, target, err)
+}
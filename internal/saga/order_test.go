@@ -0,0 +1,60 @@
+package saga_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mlucas4330/orderflow-pro/internal/events"
+	"github.com/mlucas4330/orderflow-pro/internal/repository"
+	"github.com/mlucas4330/orderflow-pro/internal/saga"
+	"github.com/mlucas4330/orderflow-pro/pkg/model"
+	kafka "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderSaga(t *testing.T) {
+	mockRepo := new(repository.MockOrderRepository)
+	orderSaga := saga.NewOrderSaga(mockRepo)
+
+	reservedID := uuid.New()
+	reserved, err := json.Marshal(events.StockReservedEvent{OrderID: reservedID})
+	require.NoError(t, err)
+
+	rejectedID := uuid.New()
+	rejected, err := json.Marshal(events.StockRejectedEvent{OrderID: rejectedID, Reason: "estoque insuficiente"})
+	require.NoError(t, err)
+
+	mockRepo.On("ConfirmOrder", context.Background(), reservedID).
+		Return(model.StatusPending, nil).Once()
+	mockRepo.On("RefundOrder", context.Background(), rejectedID, "estoque insuficiente").
+		Return(model.StatusPending, &model.TransitionError{From: model.StatusPending, To: model.StatusRefunded}).Once()
+	mockRepo.On("CancelOrder", context.Background(), rejectedID, "estoque insuficiente", uuid.Nil).
+		Return(model.StatusPending, nil).Once()
+
+	require.NoError(t, orderSaga.HandleStockReserved(context.Background(), kafka.Message{Value: reserved}))
+	require.NoError(t, orderSaga.HandleStockRejected(context.Background(), kafka.Message{Value: rejected}))
+
+	// Reentrega após o pedido já ter sido confirmado não é erro.
+	mockRepo.On("ConfirmOrder", context.Background(), reservedID).
+		Return(model.StatusConfirmed, &model.TransitionError{From: model.StatusConfirmed, To: model.StatusConfirmed}).Once()
+	require.NoError(t, orderSaga.HandleStockReserved(context.Background(), kafka.Message{Value: reserved}))
+
+	// Pedido pago cuja reserva vencida não pôde ser efetivada é estornado, não cancelado.
+	paidID := uuid.New()
+	lost, err := json.Marshal(events.StockRejectedEvent{OrderID: paidID, Reason: "estoque insuficiente"})
+	require.NoError(t, err)
+	mockRepo.On("RefundOrder", context.Background(), paidID, "estoque insuficiente").
+		Return(model.StatusPaid, nil).Once()
+	require.NoError(t, orderSaga.HandleStockRejected(context.Background(), kafka.Message{Value: lost}))
+
+	// Pedido pago antes do vencimento da reserva não é cancelado.
+	expired, err := json.Marshal(events.StockExpiredEvent{OrderID: reservedID})
+	require.NoError(t, err)
+	mockRepo.On("ExpireOrder", context.Background(), reservedID, "prazo de pagamento expirado").
+		Return(model.StatusPaid, &model.TransitionError{From: model.StatusPaid, To: model.StatusCancelled}).Once()
+	require.NoError(t, orderSaga.HandleStockExpired(context.Background(), kafka.Message{Value: expired}))
+
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const authorizationHeader = "authorization"
+
+// writeMethods são as RPCs que alteram o catálogo. Só o order-service, que já
+// exige products:manage na API REST, pode chamá-las.
+var writeMethods = map[string]bool{
+	"/product.ProductService/CreateProduct":       true,
+	"/product.ProductService/UpdateProduct":       true,
+	"/product.ProductService/DeactivateProduct":   true,
+	"/product.ProductService/SchedulePriceChange": true,
+}
+
+// NewServiceAuthInterceptor exige a credencial de serviço nas RPCs de escrita;
+// as de leitura continuam abertas. Com token vazio nenhuma escrita é aceita.
+func NewServiceAuthInterceptor(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !writeMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get(authorizationHeader)
+		if len(values) == 0 {
+			return nil, status.Error(codes.Unauthenticated, "credencial de serviço ausente")
+		}
+
+		presented, ok := strings.CutPrefix(values[0], "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			return nil, status.Error(codes.PermissionDenied, "credencial de serviço inválida")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+type serviceToken string
+
+// NewServiceCredentials envia a credencial de serviço em cada chamada ao
+// product-service. A conexão é interna e sem TLS, então não exige segurança de
+// transporte.
+func NewServiceCredentials(token string) credentials.PerRPCCredentials {
+	return serviceToken(token)
+}
+
+func (t serviceToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{authorizationHeader: "Bearer " + string(t)}, nil
+}
+
+func (t serviceToken) RequireTransportSecurity() bool {
+	return false
+}
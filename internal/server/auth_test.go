@@ -0,0 +1,45 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mlucas4330/orderflow-pro/internal/server"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestServiceAuthInterceptor(t *testing.T) {
+	interceptor := server.NewServiceAuthInterceptor("segredo")
+	handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+	create := &grpc.UnaryServerInfo{FullMethod: "/product.ProductService/CreateProduct"}
+	read := &grpc.UnaryServerInfo{FullMethod: "/product.ProductService/GetProductDetails"}
+
+	withToken := func(value string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", value))
+	}
+
+	_, err := interceptor(context.Background(), nil, read, handler)
+	require.NoError(t, err, "As leituras do catálogo não exigem credencial")
+
+	_, err = interceptor(context.Background(), nil, create, handler)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = interceptor(withToken("Bearer outro"), nil, create, handler)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = interceptor(withToken("segredo"), nil, create, handler)
+	require.Equal(t, codes.PermissionDenied, status.Code(err), "A credencial deveria vir no formato Bearer")
+
+	md, err := server.NewServiceCredentials("segredo").GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	res, err := interceptor(withToken(md["authorization"]), nil, create, handler)
+	require.NoError(t, err, "A credencial enviada pelo order-service deveria ser aceita")
+	require.Equal(t, "ok", res)
+
+	_, err = server.NewServiceAuthInterceptor("")(withToken("Bearer "), nil, create, handler)
+	require.Equal(t, codes.PermissionDenied, status.Code(err), "Sem token configurado nenhuma escrita deveria passar")
+}
@@ -0,0 +1,403 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/mlucas4330/orderflow-pro/internal/repository"
+	"github.com/mlucas4330/orderflow-pro/pkg/model"
+	pb "github.com/mlucas4330/orderflow-pro/pkg/productpb"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ProductServer struct {
+	pb.UnimplementedProductServiceServer
+	ProductRepo repository.ProductRepository
+}
+
+func NewProductServer(productRepo repository.ProductRepository) *ProductServer {
+	return &ProductServer{ProductRepo: productRepo}
+}
+
+func (s *ProductServer) GetProductDetails(ctx context.Context, req *pb.GetProductDetailsRequest) (*pb.GetProductDetailsResponse, error) {
+	id, err := uuid.Parse(req.GetProductId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "ID de produto inválido: %q", req.GetProductId())
+	}
+
+	product, err := s.ProductRepo.FindProductById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "produto %s não encontrado", id)
+		}
+
+		log.Printf("Erro ao buscar produto %s no repositório: %v", id, err)
+		return nil, status.Error(codes.Internal, "erro interno ao buscar o produto")
+	}
+
+	if !product.IsActive {
+		return nil, status.Errorf(codes.FailedPrecondition, "produto %s está inativo", id)
+	}
+
+	return toProductDetails(product), nil
+}
+
+// maxBatchSize limita o tamanho do lote para manter a consulta e a resposta
+// dentro de limites razoáveis.
+const maxBatchSize = 500
+
+func (s *ProductServer) BatchGetProductDetails(ctx context.Context, req *pb.BatchGetProductDetailsRequest) (*pb.BatchGetProductDetailsResponse, error) {
+	if len(req.GetProductIds()) > maxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "no máximo %d produtos por requisição", maxBatchSize)
+	}
+
+	res := &pb.BatchGetProductDetailsResponse{}
+
+	ids := make([]uuid.UUID, 0, len(req.GetProductIds()))
+	seen := make(map[uuid.UUID]bool, len(req.GetProductIds()))
+	for _, raw := range req.GetProductIds() {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			res.MissingIds = append(res.MissingIds, raw)
+			continue
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	products, err := s.ProductRepo.FindProductsByIds(ctx, ids)
+	if err != nil {
+		log.Printf("Erro ao buscar produtos em lote no repositório: %v", err)
+		return nil, status.Error(codes.Internal, "erro interno ao buscar os produtos")
+	}
+
+	found := make(map[uuid.UUID]*model.Product, len(products))
+	for i := range products {
+		found[products[i].ID] = &products[i]
+	}
+
+	for _, id := range ids {
+		product, ok := found[id]
+		switch {
+		case !ok:
+			res.MissingIds = append(res.MissingIds, id.String())
+		case !product.IsActive:
+			res.InactiveIds = append(res.InactiveIds, id.String())
+		default:
+			res.Products = append(res.Products, toProductDetails(product))
+		}
+	}
+
+	return res, nil
+}
+
+func toProductDetails(product *model.Product) *pb.GetProductDetailsResponse {
+	return &pb.GetProductDetailsResponse{
+		Id:            product.ID.String(),
+		Name:          product.Name,
+		Price:         product.Price.StringFixed(2),
+		Sku:           product.SKU,
+		StockQuantity: int32(product.StockQuantity),
+	}
+}
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+func (s *ProductServer) CreateProduct(ctx context.Context, req *pb.CreateProductRequest) (*pb.Product, error) {
+	name := strings.TrimSpace(req.GetName())
+	sku := strings.TrimSpace(req.GetSku())
+	if name == "" || sku == "" {
+		return nil, status.Error(codes.InvalidArgument, "nome e SKU são obrigatórios")
+	}
+
+	price, err := parsePrice(req.GetPrice())
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetStockQuantity() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "o estoque não pode ser negativo")
+	}
+
+	now := time.Now().UTC()
+	product := &model.Product{
+		ID:            uuid.New(),
+		Name:          name,
+		SKU:           sku,
+		Price:         price,
+		StockQuantity: int(req.GetStockQuantity()),
+		IsActive:      true,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if err := s.ProductRepo.CreateProduct(ctx, product); err != nil {
+		return nil, productError(err, "criar")
+	}
+
+	return toProduct(product), nil
+}
+
+func (s *ProductServer) UpdateProduct(ctx context.Context, req *pb.UpdateProductRequest) (*pb.Product, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "ID de produto inválido: %q", req.GetId())
+	}
+
+	var update repository.ProductUpdate
+
+	if req.Name != nil {
+		name := strings.TrimSpace(req.GetName())
+		if name == "" {
+			return nil, status.Error(codes.InvalidArgument, "o nome não pode ser vazio")
+		}
+		update.Name = &name
+	}
+
+	if req.Sku != nil {
+		sku := strings.TrimSpace(req.GetSku())
+		if sku == "" {
+			return nil, status.Error(codes.InvalidArgument, "o SKU não pode ser vazio")
+		}
+		update.SKU = &sku
+	}
+
+	if req.Price != nil {
+		price, err := parsePrice(req.GetPrice())
+		if err != nil {
+			return nil, err
+		}
+		update.Price = &price
+	}
+
+	if req.StockQuantity != nil {
+		if req.GetStockQuantity() < 0 {
+			return nil, status.Error(codes.InvalidArgument, "o estoque não pode ser negativo")
+		}
+		stock := int(req.GetStockQuantity())
+		update.StockQuantity = &stock
+	}
+
+	update.IsActive = req.IsActive
+
+	product, err := s.ProductRepo.UpdateProduct(ctx, id, update)
+	if err != nil {
+		return nil, productError(err, "atualizar")
+	}
+
+	return toProduct(product), nil
+}
+
+func (s *ProductServer) DeactivateProduct(ctx context.Context, req *pb.DeactivateProductRequest) (*pb.Product, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "ID de produto inválido: %q", req.GetId())
+	}
+
+	inactive := false
+	product, err := s.ProductRepo.UpdateProduct(ctx, id, repository.ProductUpdate{IsActive: &inactive})
+	if err != nil {
+		return nil, productError(err, "desativar")
+	}
+
+	return toProduct(product), nil
+}
+
+func (s *ProductServer) ListProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	pageSize := int(req.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+
+	var afterSKU string
+	if req.GetPageToken() != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(req.GetPageToken())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "page_token inválido")
+		}
+		afterSKU = string(decoded)
+	}
+
+	// Busca um item a mais para saber se existe uma próxima página.
+	products, err := s.ProductRepo.ListProducts(ctx, repository.ProductFilter{
+		Active:    req.Active,
+		Name:      strings.TrimSpace(req.GetName()),
+		SKUPrefix: strings.TrimSpace(req.GetSku()),
+		AfterSKU:  afterSKU,
+		Limit:     pageSize + 1,
+	})
+	if err != nil {
+		log.Printf("Erro ao listar produtos no repositório: %v", err)
+		return nil, status.Error(codes.Internal, "erro interno ao listar os produtos")
+	}
+
+	res := &pb.ListProductsResponse{}
+	if len(products) > pageSize {
+		products = products[:pageSize]
+		res.NextPageToken = base64.RawURLEncoding.EncodeToString([]byte(products[pageSize-1].SKU))
+	}
+
+	for i := range products {
+		res.Products = append(res.Products, toProduct(&products[i]))
+	}
+
+	return res, nil
+}
+
+func (s *ProductServer) GetProductBySku(ctx context.Context, req *pb.GetProductBySkuRequest) (*pb.Product, error) {
+	sku := strings.TrimSpace(req.GetSku())
+	if sku == "" {
+		return nil, status.Error(codes.InvalidArgument, "o SKU é obrigatório")
+	}
+
+	product, err := s.ProductRepo.FindProductBySku(ctx, sku)
+	if err != nil {
+		return nil, productError(err, "buscar")
+	}
+
+	return toProduct(product), nil
+}
+
+func (s *ProductServer) GetPriceAt(ctx context.Context, req *pb.GetPriceAtRequest) (*pb.PriceEntry, error) {
+	id, err := uuid.Parse(req.GetProductId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "ID de produto inválido: %q", req.GetProductId())
+	}
+
+	at := time.Now().UTC()
+	if req.GetAt() != "" {
+		at, err = time.Parse(time.RFC3339, req.GetAt())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "data inválida, use RFC 3339: %q", req.GetAt())
+		}
+	}
+
+	price, err := s.ProductRepo.FindPriceAt(ctx, id, at)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "produto %s sem preço em %s", id, at.Format(time.RFC3339))
+		}
+		log.Printf("Erro ao buscar preço do produto %s no repositório: %v", id, err)
+		return nil, status.Error(codes.Internal, "erro interno ao buscar o preço")
+	}
+
+	return toPriceEntry(price), nil
+}
+
+func (s *ProductServer) SchedulePriceChange(ctx context.Context, req *pb.SchedulePriceChangeRequest) (*pb.PriceEntry, error) {
+	id, err := uuid.Parse(req.GetProductId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "ID de produto inválido: %q", req.GetProductId())
+	}
+
+	value, err := parsePrice(req.GetPrice())
+	if err != nil {
+		return nil, err
+	}
+
+	effectiveFrom, err := time.Parse(time.RFC3339, req.GetEffectiveFrom())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "data de vigência inválida, use RFC 3339: %q", req.GetEffectiveFrom())
+	}
+
+	if !effectiveFrom.After(time.Now()) {
+		return nil, status.Error(codes.InvalidArgument, "a data de vigência precisa estar no futuro")
+	}
+
+	price := &model.ProductPrice{ProductID: id, Price: value, EffectiveFrom: effectiveFrom.UTC()}
+	if err := s.ProductRepo.SchedulePrice(ctx, price); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, status.Error(codes.NotFound, "produto não encontrado")
+		case errors.Is(err, repository.ErrDuplicatePriceSchedule):
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+		log.Printf("Erro ao programar preço do produto %s no repositório: %v", id, err)
+		return nil, status.Error(codes.Internal, "erro interno ao programar o preço")
+	}
+
+	return toPriceEntry(price), nil
+}
+
+func (s *ProductServer) ListPriceHistory(ctx context.Context, req *pb.ListPriceHistoryRequest) (*pb.ListPriceHistoryResponse, error) {
+	id, err := uuid.Parse(req.GetProductId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "ID de produto inválido: %q", req.GetProductId())
+	}
+
+	prices, err := s.ProductRepo.ListPrices(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "produto %s não encontrado", id)
+		}
+		log.Printf("Erro ao buscar histórico de preços do produto %s no repositório: %v", id, err)
+		return nil, status.Error(codes.Internal, "erro interno ao buscar o histórico de preços")
+	}
+
+	res := &pb.ListPriceHistoryResponse{}
+	for i := range prices {
+		res.Prices = append(res.Prices, toPriceEntry(&prices[i]))
+	}
+
+	return res, nil
+}
+
+func parsePrice(raw string) (decimal.Decimal, error) {
+	price, err := decimal.NewFromString(raw)
+	if err != nil {
+		return decimal.Decimal{}, status.Errorf(codes.InvalidArgument, "preço inválido: %q", raw)
+	}
+	if !price.IsPositive() {
+		return decimal.Decimal{}, status.Error(codes.InvalidArgument, "o preço deve ser maior que zero")
+	}
+	return price, nil
+}
+
+// productError traduz os erros do repositório para códigos gRPC.
+func productError(err error, action string) error {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return status.Error(codes.NotFound, "produto não encontrado")
+	case errors.Is(err, repository.ErrDuplicateSKU):
+		return status.Error(codes.AlreadyExists, err.Error())
+	default:
+		log.Printf("Erro ao %s produto no repositório: %v", action, err)
+		return status.Errorf(codes.Internal, "erro interno ao %s o produto", action)
+	}
+}
+
+func toProduct(product *model.Product) *pb.Product {
+	return &pb.Product{
+		Id:            product.ID.String(),
+		Name:          product.Name,
+		Sku:           product.SKU,
+		Price:         product.Price.StringFixed(2),
+		StockQuantity: int32(product.StockQuantity),
+		IsActive:      product.IsActive,
+		CreatedAt:     product.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     product.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func toPriceEntry(price *model.ProductPrice) *pb.PriceEntry {
+	return &pb.PriceEntry{
+		ProductId:     price.ProductID.String(),
+		Price:         price.Price.StringFixed(2),
+		EffectiveFrom: price.EffectiveFrom.Format(time.RFC3339),
+		CreatedAt:     price.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,147 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/mlucas4330/orderflow-pro/internal/repository"
+	"github.com/mlucas4330/orderflow-pro/internal/server"
+	"github.com/mlucas4330/orderflow-pro/pkg/model"
+	pb "github.com/mlucas4330/orderflow-pro/pkg/productpb"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetProductDetails(t *testing.T) {
+	mockRepo := new(repository.MockProductRepository)
+	productServer := server.NewProductServer(mockRepo)
+	ctx := context.Background()
+
+	active := &model.Product{ID: uuid.New(), Name: "Café", SKU: "CF-1", Price: decimal.RequireFromString("55.9"), StockQuantity: 7, IsActive: true}
+	inactive := &model.Product{ID: uuid.New(), SKU: "CF-2", IsActive: false}
+	missing := uuid.New()
+
+	mockRepo.On("FindProductById", ctx, active.ID).Return(active, nil)
+	mockRepo.On("FindProductById", ctx, inactive.ID).Return(inactive, nil)
+	mockRepo.On("FindProductById", ctx, missing).Return(nil, pgx.ErrNoRows)
+
+	res, err := productServer.GetProductDetails(ctx, &pb.GetProductDetailsRequest{ProductId: active.ID.String()})
+	require.NoError(t, err)
+	require.Equal(t, "55.90", res.GetPrice())
+	require.Equal(t, "CF-1", res.GetSku())
+	require.EqualValues(t, 7, res.GetStockQuantity())
+
+	_, err = productServer.GetProductDetails(ctx, &pb.GetProductDetailsRequest{ProductId: inactive.ID.String()})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = productServer.GetProductDetails(ctx, &pb.GetProductDetailsRequest{ProductId: missing.String()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = productServer.GetProductDetails(ctx, &pb.GetProductDetailsRequest{ProductId: "abc"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestBatchGetProductDetails(t *testing.T) {
+	mockRepo := new(repository.MockProductRepository)
+	productServer := server.NewProductServer(mockRepo)
+	ctx := context.Background()
+
+	active := model.Product{ID: uuid.New(), SKU: "CF-1", Price: decimal.RequireFromString("10"), IsActive: true}
+	inactive := model.Product{ID: uuid.New(), SKU: "CF-2"}
+	missing := uuid.New()
+
+	mockRepo.On("FindProductsByIds", ctx, []uuid.UUID{active.ID, inactive.ID, missing}).
+		Return([]model.Product{inactive, active}, nil)
+
+	res, err := productServer.BatchGetProductDetails(ctx, &pb.BatchGetProductDetailsRequest{
+		ProductIds: []string{active.ID.String(), inactive.ID.String(), missing.String(), active.ID.String(), "abc"},
+	})
+	require.NoError(t, err)
+	require.Len(t, res.GetProducts(), 1)
+	require.Equal(t, "10.00", res.GetProducts()[0].GetPrice())
+	require.Equal(t, []string{"abc", missing.String()}, res.GetMissingIds())
+	require.Equal(t, []string{inactive.ID.String()}, res.GetInactiveIds())
+}
+
+func TestListProductsPagination(t *testing.T) {
+	mockRepo := new(repository.MockProductRepository)
+	productServer := server.NewProductServer(mockRepo)
+	ctx := context.Background()
+
+	active := true
+	mockRepo.On("ListProducts", ctx, repository.ProductFilter{Active: &active, SKUPrefix: "CF", Limit: 3}).
+		Return([]model.Product{{SKU: "CF-1"}, {SKU: "CF-2"}, {SKU: "CF-3"}}, nil)
+	mockRepo.On("ListProducts", ctx, repository.ProductFilter{Active: &active, SKUPrefix: "CF", AfterSKU: "CF-2", Limit: 3}).
+		Return([]model.Product{{SKU: "CF-3"}}, nil)
+
+	res, err := productServer.ListProducts(ctx, &pb.ListProductsRequest{PageSize: 2, Active: &active, Sku: "CF"})
+	require.NoError(t, err)
+	require.Len(t, res.GetProducts(), 2)
+	require.NotEmpty(t, res.GetNextPageToken())
+
+	res, err = productServer.ListProducts(ctx, &pb.ListProductsRequest{PageSize: 2, Active: &active, Sku: "CF", PageToken: res.GetNextPageToken()})
+	require.NoError(t, err)
+	require.Len(t, res.GetProducts(), 1)
+	require.Empty(t, res.GetNextPageToken())
+}
+
+func TestCreateProductDuplicateSKU(t *testing.T) {
+	mockRepo := new(repository.MockProductRepository)
+	productServer := server.NewProductServer(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("CreateProduct", ctx, mock.AnythingOfType("*model.Product")).Return(repository.ErrDuplicateSKU)
+
+	_, err := productServer.CreateProduct(ctx, &pb.CreateProductRequest{Name: "Café", Sku: "CF-1", Price: "10"})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = productServer.CreateProduct(ctx, &pb.CreateProductRequest{Name: "Café", Sku: "CF-1", Price: "-1"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = productServer.CreateProduct(ctx, &pb.CreateProductRequest{Name: "Café", Sku: "CF-1", Price: "0"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err), "Preço zero deveria ser rejeitado")
+
+	_, err = productServer.CreateProduct(ctx, &pb.CreateProductRequest{Name: "Café", Sku: "CF-1"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err), "Preço ausente deveria ser rejeitado")
+}
+
+func TestPriceHistoryRPCs(t *testing.T) {
+	mockRepo := new(repository.MockProductRepository)
+	productServer := server.NewProductServer(mockRepo)
+	ctx := context.Background()
+
+	productID := uuid.New()
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	mockRepo.On("FindPriceAt", ctx, productID, at).
+		Return(&model.ProductPrice{ProductID: productID, Price: decimal.RequireFromString("49.9"), EffectiveFrom: at.Add(-time.Hour)}, nil)
+
+	entry, err := productServer.GetPriceAt(ctx, &pb.GetPriceAtRequest{ProductId: productID.String(), At: at.Format(time.RFC3339)})
+	require.NoError(t, err)
+	require.Equal(t, "49.90", entry.GetPrice())
+
+	_, err = productServer.SchedulePriceChange(ctx, &pb.SchedulePriceChangeRequest{
+		ProductId:     productID.String(),
+		Price:         "59.90",
+		EffectiveFrom: time.Now().Add(-time.Minute).Format(time.RFC3339),
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err), "Preço programado no passado deveria ser rejeitado")
+
+	mockRepo.On("SchedulePrice", ctx, mock.AnythingOfType("*model.ProductPrice")).Return(repository.ErrDuplicatePriceSchedule).Once()
+	_, err = productServer.SchedulePriceChange(ctx, &pb.SchedulePriceChangeRequest{
+		ProductId:     productID.String(),
+		Price:         "59.90",
+		EffectiveFrom: time.Now().Add(time.Hour).Format(time.RFC3339),
+	})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	missing := uuid.New()
+	mockRepo.On("ListPrices", ctx, missing).Return(nil, pgx.ErrNoRows)
+	_, err = productServer.ListPriceHistory(ctx, &pb.ListPriceHistoryRequest{ProductId: missing.String()})
+	require.Equal(t, codes.NotFound, status.Code(err), "Histórico de produto inexistente deveria responder NotFound")
+}
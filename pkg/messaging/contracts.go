@@ -5,11 +5,16 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+const (
+	EmailNotificationsQueue = "email_notifications"
+	NotificationType        = "notification.email"
+)
+
 type OrderCreatedEvent struct {
 	OrderID    uuid.UUID       `json:"order_id"`
 	CustomerID uuid.UUID       `json:"customer_id"`
 	Total      decimal.Decimal `json:"total"`
-	Items      []OrderItem   `json:"items"`
+	Items      []OrderItem     `json:"items"`
 }
 
 type OrderItem struct {
@@ -21,4 +26,4 @@ type NotificationPayload struct {
 	OrderID    string `json:"order_id"`
 	CustomerID string `json:"customer_id"`
 	Message    string `json:"message"`
-}
\ No newline at end of file
+}
@@ -7,14 +7,23 @@ import (
 )
 
 type IdempotencyKey struct {
-	Key        uuid.UUID `db:"idempotency_key"`
-	UserID     uuid.UUID `db:"user_id"`
-	StatusCode int       `db:"response_status_code"`
-	Body       []byte    `db:"response_body"`
-	CreatedAt  time.Time `db:"created_at"`
+	Key        uuid.UUID           `db:"idempotency_key"`
+	UserID     uuid.UUID           `db:"user_id"`
+	Status     string              `db:"status"`
+	StatusCode int                 `db:"response_status_code"`
+	Headers    map[string][]string `db:"response_headers"`
+	Body       []byte              `db:"response_body"`
+	// RequestHash é o SHA-256 do método, caminho e corpo da requisição
+	// original; chaves gravadas antes dele existir ficam vazias.
+	RequestHash string     `db:"request_hash"`
+	LockedUntil *time.Time `db:"locked_until"`
+	ExpiresAt   *time.Time `db:"expires_at"`
+	CreatedAt   time.Time  `db:"created_at"`
 }
 
 type IdempotencyResponse struct {
-	StatusCode int
-	Body       []byte
+	StatusCode  int
+	Headers     map[string][]string
+	Body        []byte
+	RequestHash string
 }
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,6 +13,7 @@ type Status string
 
 const (
 	StatusPending   Status = "pending"
+	StatusConfirmed Status = "confirmed"
 	StatusPaid      Status = "paid"
 	StatusShipped   Status = "shipped"
 	StatusDelivered Status = "delivered"
@@ -18,13 +21,57 @@ const (
 	StatusRefunded  Status = "refunded"
 )
 
+// statusTransitions define o ciclo de vida do pedido. Um pedido pago não é mais
+// cancelado: ele só sai de paid pelo envio ou pelo reembolso, que exige
+// orders:pay, para que o cliente não desfaça uma venda já paga sem estorno.
+var statusTransitions = map[Status][]Status{
+	StatusPending:   {StatusConfirmed, StatusCancelled},
+	StatusConfirmed: {StatusPaid, StatusCancelled},
+	StatusPaid:      {StatusShipped, StatusRefunded},
+	StatusShipped:   {StatusDelivered},
+	StatusDelivered: {StatusRefunded},
+	StatusCancelled: {},
+	StatusRefunded:  {},
+}
+
+func (s Status) IsValid() bool {
+	_, ok := statusTransitions[s]
+	return ok
+}
+
+func (s Status) AllowedTransitions() []Status {
+	return slices.Clone(statusTransitions[s])
+}
+
+func (s Status) CanTransitionTo(next Status) bool {
+	return slices.Contains(statusTransitions[s], next)
+}
+
+func Statuses() []Status {
+	return []Status{StatusPending, StatusConfirmed, StatusPaid, StatusShipped, StatusDelivered, StatusCancelled, StatusRefunded}
+}
+
+type TransitionError struct {
+	From Status
+	To   Status
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("transição de status inválida: %s -> %s", e.From, e.To)
+}
+
+func (e *TransitionError) Allowed() []Status {
+	return e.From.AllowedTransitions()
+}
+
 type Order struct {
-	ID         uuid.UUID       `db:"id"`
-	CustomerID uuid.UUID       `db:"customer_id"`
-	Status     Status          `db:"status"`
-	Total      decimal.Decimal `db:"total"`
-	Currency   string          `db:"currency"`
-	OrderItems []OrderItem     `db:"-"`
-	CreatedAt  time.Time       `db:"created_at"`
-	UpdatedAt  time.Time       `db:"updated_at"`
+	ID                 uuid.UUID       `db:"id"`
+	CustomerID         uuid.UUID       `db:"customer_id"`
+	Status             Status          `db:"status"`
+	CancellationReason *string         `db:"cancellation_reason"`
+	Total              decimal.Decimal `db:"total"`
+	Currency           string          `db:"currency"`
+	OrderItems         []OrderItem     `db:"-"`
+	CreatedAt          time.Time       `db:"created_at"`
+	UpdatedAt          time.Time       `db:"updated_at"`
 }
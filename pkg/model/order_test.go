@@ -0,0 +1,37 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusTransitions(t *testing.T) {
+	cases := []struct {
+		from, to Status
+		allowed  bool
+	}{
+		{StatusPending, StatusConfirmed, true},
+		{StatusPending, StatusPaid, false},
+		{StatusPending, StatusCancelled, true},
+		{StatusPending, StatusShipped, false},
+		{StatusConfirmed, StatusPaid, true},
+		{StatusConfirmed, StatusCancelled, true},
+		{StatusPaid, StatusShipped, true},
+		{StatusPaid, StatusRefunded, true},
+		{StatusPaid, StatusCancelled, false},
+		{StatusShipped, StatusDelivered, true},
+		{StatusShipped, StatusCancelled, false},
+		{StatusDelivered, StatusPending, false},
+		{StatusDelivered, StatusRefunded, true},
+		{StatusCancelled, StatusPaid, false},
+		{StatusRefunded, StatusPending, false},
+	}
+
+	for _, tc := range cases {
+		require.Equal(t, tc.allowed, tc.from.CanTransitionTo(tc.to), "%s -> %s", tc.from, tc.to)
+	}
+
+	require.False(t, Status("archived").IsValid())
+	require.Empty(t, StatusCancelled.AllowedTransitions())
+}
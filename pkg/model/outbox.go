@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OutboxDestination string
+
+const (
+	OutboxDestinationKafka    OutboxDestination = "kafka"
+	OutboxDestinationRabbitMQ OutboxDestination = "rabbitmq"
+)
+
+type OutboxMessage struct {
+	ID            int64             `db:"id"`
+	AggregateID   uuid.UUID         `db:"aggregate_id"`
+	EventType     string            `db:"event_type"`
+	Destination   OutboxDestination `db:"destination"`
+	Topic         string            `db:"topic"`
+	Payload       []byte            `db:"payload"`
+	Attempts      int               `db:"attempts"`
+	LastError     *string           `db:"last_error"`
+	NextAttemptAt time.Time         `db:"next_attempt_at"`
+	CreatedAt     time.Time         `db:"created_at"`
+	PublishedAt   *time.Time        `db:"published_at"`
+	ParkedAt      *time.Time        `db:"parked_at"`
+}
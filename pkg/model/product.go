@@ -17,3 +17,12 @@ type Product struct {
 	CreatedAt     time.Time       `db:"created_at"`
 	UpdatedAt     time.Time       `db:"updated_at"`
 }
+
+// ProductPrice é uma entrada do histórico de preços. O preço vale a partir de
+// EffectiveFrom até a próxima entrada do mesmo produto.
+type ProductPrice struct {
+	ProductID     uuid.UUID       `db:"product_id"`
+	Price         decimal.Decimal `db:"price"`
+	EffectiveFrom time.Time       `db:"effective_from"`
+	CreatedAt     time.Time       `db:"created_at"`
+}